@@ -0,0 +1,116 @@
+package EventBus
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// SubscribeInBatches subscribes fn to a topic, fn receives the events in batches of up to maxSize events.
+// A batch is flushed once it is full, once its first event waited for maxWait, when the subscription
+// is removed and when the bus is closed.
+// A maxSize or maxWait of 0 means no limit, a batch holds a single event if both are 0.
+// fn is called by the publisher filling the batch or by a timer goroutine, never concurrently.
+// Unlike SubscribeBatch, which subscribes the methods of a struct, it subscribes a single function.
+func (bus *EventBus) SubscribeInBatches(topic string, fn func([]Event), maxSize int, maxWait time.Duration, opts ...SubscribeOption) (Subscription, error) {
+	if maxSize <= 0 && maxWait <= 0 {
+		maxSize = 1
+	}
+	handler := &eventHandler{callBack: reflect.ValueOf(fn)}
+	for _, opt := range opts {
+		opt(handler)
+	}
+	handler.batch = &batcher{handler: handler, maxSize: maxSize, maxWait: maxWait}
+	sub, err := bus.doSubscribe(topic, fn, handler)
+	if err != nil {
+		return nil, err
+	}
+	bus.batchers.Store(handler, handler.batch)
+	return sub, nil
+}
+
+// batcher collects the events of a handler subscribed with SubscribeInBatches
+type batcher struct {
+	lock      sync.Mutex
+	flushLock sync.Mutex // serializes the calls of the handler
+	handler   *eventHandler
+	maxSize   int
+	maxWait   time.Duration
+	events    []Event
+	timer     *time.Timer
+	batch     uint64        // number of the current batch, a timer only flushes its own batch
+	call      *inflightCall // keeps Drain waiting for the current batch
+	stopped   bool          // the handler is unsubscribed, events are not collected anymore
+}
+
+func (b *batcher) add(event Event) {
+	b.lock.Lock()
+	if b.stopped {
+		b.lock.Unlock()
+		return // 已经取消订阅
+	}
+	b.events = append(b.events, event)
+	if len(b.events) == 1 && b.maxWait > 0 {
+		b.call = b.handler.bus.running.add(b.handler.topic, b.handler)
+		batch := b.batch
+		b.timer = time.AfterFunc(b.maxWait, func() { b.flushBatch(batch) })
+	}
+	full := b.maxSize > 0 && len(b.events) >= b.maxSize
+	b.lock.Unlock()
+	if full {
+		b.flush()
+	}
+}
+
+// stop flushes the collected events once the handler is unsubscribed. The flush runs in its own
+// goroutine, the caller holds the lock of the bus, Drain and Close wait for it.
+func (b *batcher) stop() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.stopped = true
+	if len(b.events) == 0 {
+		return
+	}
+	if b.call == nil {
+		b.call = b.handler.bus.running.add(b.handler.topic, b.handler)
+	}
+	go b.flush()
+}
+
+// flushBatch flushes the events if the current batch is the batch of the timer
+func (b *batcher) flushBatch(batch uint64) {
+	b.doFlush(func(current uint64) bool { return current == batch })
+}
+
+// flush passes the collected events to the handler
+func (b *batcher) flush() {
+	b.doFlush(func(uint64) bool { return true })
+}
+
+func (b *batcher) doFlush(accept func(batch uint64) bool) {
+	b.flushLock.Lock()
+	defer b.flushLock.Unlock()
+	b.lock.Lock()
+	if !accept(b.batch) {
+		b.lock.Unlock()
+		return // 该批次已经提交
+	}
+	events, call := b.events, b.call
+	b.events, b.call = nil, nil
+	b.batch++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.lock.Unlock()
+
+	bus, handler := b.handler.bus, b.handler
+	if len(events) > 0 {
+		if err := bus.invoke(handler.topic, handler, []reflect.Value{reflect.ValueOf(events)}, []interface{}{events}); err != nil {
+			bus.handleError(handler.topic, err)
+		}
+	}
+	if call != nil {
+		bus.running.done(call)
+	}
+}
@@ -0,0 +1,124 @@
+package EventBus_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestSubscribeInBatches(t *testing.T) {
+	bus := EventBus.New()
+	var lock sync.Mutex
+	batches := []string{}
+	sub, err := bus.SubscribeInBatches("rows:#", func(events []EventBus.Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		batch := []string{}
+		for _, e := range events {
+			batch = append(batch, fmt.Sprint(e.Topic, e.Payload))
+		}
+		batches = append(batches, fmt.Sprint(batch))
+	}, 3, 30*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func() string {
+		lock.Lock()
+		defer lock.Unlock()
+		return fmt.Sprint(batches)
+	}
+
+	bus.Publish("rows:a", 1)
+	bus.Publish("rows:b", 2, "x")
+	bus.Publish("rows:a", 3)
+	// full batch flushed by the publisher
+	if get() != "[[rows:a[1] rows:b[2 x] rows:a[3]]]" {
+		t.Fatal(get())
+	}
+	bus.Publish("rows:c", 4)
+	if err := bus.Drain(context.Background()); err != nil { // waits for the timeout of the batch
+		t.Fatal(err)
+	}
+	if get() != "[[rows:a[1] rows:b[2 x] rows:a[3]] [rows:c[4]]]" {
+		t.Fatal(get())
+	}
+	if sub.Stats().Calls != 2 {
+		t.Fatal(sub.Stats())
+	}
+}
+
+func TestSubscribeInBatchesUnsubscribe(t *testing.T) {
+	for _, maxWait := range []time.Duration{0, 20 * time.Millisecond} {
+		bus := EventBus.New()
+		var lock sync.Mutex
+		batches := []int{}
+		sub, _ := bus.SubscribeInBatches("rows", func(events []EventBus.Event) {
+			lock.Lock()
+			defer lock.Unlock()
+			batches = append(batches, len(events))
+		}, 100, maxWait)
+		bus.Publish("rows", 1)
+		bus.Publish("rows", 2)
+
+		// the collected events are flushed once, the pending timer does not call the handler again
+		sub.Unsubscribe()
+		bus.Publish("rows", 3)
+		if err := bus.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * maxWait)
+		lock.Lock()
+		if fmt.Sprint(batches) != "[2]" {
+			t.Fatal(maxWait, batches)
+		}
+		lock.Unlock()
+	}
+}
+
+func TestSubscribeInBatchesClose(t *testing.T) {
+	bus := EventBus.New()
+	received := 0
+	bus.SubscribeInBatches("rows", func(events []EventBus.Event) { received += len(events) }, 100, time.Hour)
+	for i := 0; i < 10; i++ {
+		bus.Publish("rows", i)
+	}
+	start := time.Now()
+	// Close flushes the pending batch without waiting for maxWait
+	if err := bus.Close(context.Background()); err != nil || received != 10 || time.Since(start) > time.Second {
+		t.Fatal(err, received)
+	}
+}
+
+func TestSubscribeInBatchesRequest(t *testing.T) {
+	bus := EventBus.New(EventBus.WithStrictArguments()).(*EventBus.EventBus)
+	if err := bus.DeclareTopic("rows:insert", reflect.TypeOf(0)); err != nil {
+		t.Fatal(err)
+	}
+	// batch handlers are not checked against the declared arguments
+	if _, err := bus.SubscribeInBatches("rows:insert", func(events []EventBus.Event) {}, 10, 0); err != nil {
+		t.Fatal(err)
+	}
+	bus.Subscribe("rows:insert", func(i int) int { return i })
+
+	// nor called by RequestAll
+	results, err := bus.RequestAll(context.Background(), "rows:insert", 1)
+	if err != nil || fmt.Sprint(results) != "[[1]]" {
+		t.Fatal(results, err)
+	}
+}
+
+func TestSubscribeInBatchesDeclareAfter(t *testing.T) {
+	bus := EventBus.New().(*EventBus.EventBus)
+	if _, err := bus.SubscribeInBatches("rows", func(events []EventBus.Event) {}, 10, 0); err != nil {
+		t.Fatal(err)
+	}
+	// a topic declared after the batch subscription does not check it either
+	if err := bus.DeclareTopic("rows", reflect.TypeOf(0)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,7 @@
 package EventBus
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -18,6 +19,7 @@ const (
 type ClientArg struct {
 	Args  []interface{}
 	Topic string
+	Event *Event // envelope of the remote event, without payload
 }
 
 // Client - object capable of subscribing to a remote event bus
@@ -121,8 +123,21 @@ type ClientService struct {
 }
 
 // PushEvent - exported service to listening to remote events
+// The envelope of the remote event, if any, is passed to the local handlers taking one,
+// with the topic the event was published to on the server.
 func (service *ClientService) PushEvent(arg *ClientArg, reply *bool) error {
-	service.client.eventBus.Publish(arg.Topic, arg.Args...)
+	if arg.Event != nil {
+		event := *arg.Event
+		event.Payload = arg.Args
+		if bus, ok := service.client.eventBus.(*EventBus); ok {
+			bus.publishEnvelope(context.Background(), arg.Topic, &event) // 保留事件发布的主题
+		} else {
+			event.Topic = arg.Topic // 其他 Bus 实现只能发布到信封的主题
+			service.client.eventBus.PublishEvent(context.Background(), &event)
+		}
+	} else {
+		service.client.eventBus.Publish(arg.Topic, arg.Args...)
+	}
 	*reply = true
 	return nil
 }
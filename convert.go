@@ -0,0 +1,74 @@
+package EventBus
+
+import "reflect"
+
+// WithArgumentConversion converts the arguments of an event which are not assignable to the parameters
+// of a handler but convertible without loss: numbers to other numeric types if the value is unchanged,
+// and values to named types of the same kind, e.g. string to `type Status string`.
+// Handlers subscribed with Subscribe[T] receive their argument as it is.
+func WithArgumentConversion() BusOption {
+	return func(bus *EventBus) {
+		bus.convert = true
+	}
+}
+
+// argument returns the value of v passed to a parameter of type t, false if v does not match t.
+// A nil v is the zero value of a pointer, map, slice, interface, chan or func parameter.
+func (bus *EventBus) argument(v interface{}, t reflect.Type) (reflect.Value, bool) {
+	if v == nil {
+		if !nilable(t) {
+			return reflect.Value{}, false // nil 无法转换为该类型
+		}
+		return reflect.Zero(t), true
+	}
+	value := reflect.ValueOf(v)
+	if value.Type().AssignableTo(t) {
+		return value, true
+	}
+	if !bus.convert || !convertible(value.Type(), t) {
+		return reflect.Value{}, false
+	}
+	converted := value.Convert(t)
+	if value.Kind() != t.Kind() && (converted.Convert(value.Type()).Interface() != v || negative(value) != negative(converted)) {
+		return reflect.Value{}, false // 数值溢出、精度丢失或者符号改变
+	}
+	return converted, true
+}
+
+// negative returns true if the number is below zero, e.g. -1 converted to uint is not
+func negative(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() < 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() < 0
+	}
+	return false
+}
+
+// nilable returns true if nil is a value of type t
+func nilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// convertible returns true if values of type from may be converted to type to by WithArgumentConversion
+func convertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	return from.Kind() == to.Kind() || isNumber(from) && isNumber(to)
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
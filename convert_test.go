@@ -0,0 +1,81 @@
+package EventBus_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/suisrc/EventBus"
+)
+
+type status string
+
+func TestNilArguments(t *testing.T) {
+	bus := EventBus.New()
+	calls := 0
+	bus.Subscribe("topic", func(p *int, m map[string]int, s []int, e error, f func()) {
+		if p != nil || m != nil || s != nil || e != nil || f != nil {
+			t.Fail()
+		}
+		calls++
+	})
+	bus.Subscribe("topic", func(i int, m map[string]int, s []int, e error, f func()) {
+		calls += 10 // nil is not an int
+	})
+	bus.Publish("topic", nil, nil, nil, nil, nil)
+	if calls != 1 {
+		t.Fatal(calls)
+	}
+
+	values, ok := bus.(*EventBus.EventBus).PassedArguments(reflect.TypeOf(func(string, ...*int) {}), "a", nil)
+	if !ok || len(values) != 2 || !values[1].IsNil() {
+		t.Fatal(values, ok)
+	}
+}
+
+func TestArgumentConversion(t *testing.T) {
+	bus := EventBus.New(EventBus.WithArgumentConversion())
+	var got64 int64
+	var gotStatus status
+	var got8 int8
+	bus.Subscribe("number", func(v int64) { got64 = v })
+	bus.Subscribe("number", func(v int8) { got8 = v })
+	bus.Subscribe("status", func(s status) { gotStatus = s })
+	bus.Subscribe("status", func(i int) { t.Fail() }) // no string to number conversion
+
+	bus.Publish("number", 42)
+	if got64 != 42 || got8 != 42 {
+		t.Fatal(got64, got8)
+	}
+	bus.Publish("number", 300) // overflows int8
+	if got64 != 300 || got8 != 42 {
+		t.Fatal(got64, got8)
+	}
+	bus.Publish("number", 1.5) // not an integer
+	if got64 != 300 {
+		t.Fatal(got64)
+	}
+	var gotUint uint
+	var gotInt int64
+	bus.Subscribe("signed", func(v uint) { gotUint = v })
+	bus.Subscribe("unsigned", func(v int64) { gotInt = v })
+	bus.Publish("signed", -1) // negative to unsigned
+	bus.Publish("unsigned", uint64(math.MaxUint64))
+	if gotUint != 0 || gotInt != 0 {
+		t.Fatal(gotUint, gotInt)
+	}
+	bus.Publish("signed", 7)
+	bus.Publish("unsigned", uint64(7))
+	if gotUint != 7 || gotInt != 7 {
+		t.Fatal(gotUint, gotInt)
+	}
+	bus.Publish("status", "done")
+	if gotStatus != "done" {
+		t.Fatal(gotStatus)
+	}
+
+	// without the option the handlers are skipped
+	plain := EventBus.New()
+	plain.Subscribe("number", func(v int64) { t.Fail() })
+	plain.Publish("number", 42)
+}
@@ -0,0 +1,293 @@
+package EventBus
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MissedRunPolicy determines how the runs of a recurring event missed by the scheduler are published,
+// e.g. while the scheduler is blocked by slow sync handlers or the process is suspended.
+type MissedRunPolicy int
+
+const (
+	MissedRunOnce MissedRunPolicy = iota // the missed runs are published as a single event
+	MissedRunAll                         // every missed run is published
+	MissedRunSkip                        // the missed runs are not published, only runs on time are
+)
+
+// CronEntry is the handle of an event scheduled with Schedule
+type CronEntry interface {
+	ScheduledEvent
+	// ID returns the identifier of the entry in the scheduler
+	ID() uint64
+	// Spec returns the cron expression of the entry
+	Spec() string
+}
+
+// ID returns the identifier of the entry in the scheduler
+func (e *scheduledEvent) ID() uint64 {
+	return e.id
+}
+
+// Spec returns the cron expression of the entry
+func (e *scheduledEvent) Spec() string {
+	return e.spec
+}
+
+// CronOption configures an event scheduled with Schedule
+type CronOption func(*cronConfig)
+
+type cronConfig struct {
+	location *time.Location
+	missed   MissedRunPolicy
+}
+
+// WithLocation evaluates the cron expression in the time zone, the default is time.Local
+func WithLocation(location *time.Location) CronOption {
+	return func(c *cronConfig) {
+		c.location = location
+	}
+}
+
+// WithMissedRuns sets the missed run policy, the default is MissedRunOnce
+func WithMissedRuns(policy MissedRunPolicy) CronOption {
+	return func(c *cronConfig) {
+		c.missed = policy
+	}
+}
+
+// Schedule publishes an event to the topic at the times of the cron expression, until the entry is
+// cancelled or the bus is closed. The arguments of each event are returned by argsFactory, which may be nil.
+//
+// The expression has 5 fields (minute hour day-of-month month day-of-week) or 6 fields with seconds first,
+// fields accept "*", "?", lists, ranges, steps and the names of months and days, e.g. "0 9 * * MON-FRI".
+// The descriptors @yearly, @monthly, @weekly, @daily and @hourly are accepted as well, and a
+// "CRON_TZ=<zone> " prefix overrides the time zone of WithLocation.
+func (bus *EventBus) Schedule(cronExpr, topic string, argsFactory func() []interface{}, opts ...CronOption) (CronEntry, error) {
+	cfg := cronConfig{location: time.Local}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	spec, err := parseCron(cronExpr, cfg.location)
+	if err != nil {
+		return nil, err
+	}
+	next := spec.after(time.Now())
+	if next.IsZero() {
+		return nil, fmt.Errorf("cron: %q has no run time", cronExpr)
+	}
+	if argsFactory == nil {
+		argsFactory = func() []interface{} { return nil }
+	}
+	e := &scheduledEvent{spec: cronExpr, topic: topic, next: next, repeat: spec, missed: cfg.missed, args: argsFactory}
+	if bus.Closed() || !bus.scheduler.add(bus, e) {
+		return nil, ErrBusClosed
+	}
+	return e, nil
+}
+
+// Schedules returns the entries scheduled with Schedule, in the order of their ID
+func (bus *EventBus) Schedules() []CronEntry {
+	bus.scheduler.lock.Lock()
+	defer bus.scheduler.lock.Unlock()
+	entries := make([]CronEntry, 0)
+	for _, e := range bus.scheduler.timers {
+		if e.spec != "" {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID() < entries[j].ID() })
+	return entries
+}
+
+// Unschedule cancels the entry with the id, returns false if there is none
+func (bus *EventBus) Unschedule(id uint64) bool {
+	for _, e := range bus.Schedules() {
+		if e.ID() == id {
+			return e.Cancel()
+		}
+	}
+	return false
+}
+
+// cronSpec is a parsed cron expression, each field is a bit set of the accepted values
+type cronSpec struct {
+	second, minute, hour, dom, month, dow uint64
+	anyDay                                bool // day-of-month or day-of-week is "*", both must match
+	location                              *time.Location
+}
+
+type cronField struct {
+	min, max int
+	names    []string // names of the values from min
+}
+
+var (
+	cronSeconds = cronField{0, 59, nil}
+	cronMinutes = cronField{0, 59, nil}
+	cronHours   = cronField{0, 23, nil}
+	cronDom     = cronField{1, 31, nil}
+	cronMonths  = cronField{1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	cronDow     = cronField{0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}} // 7 也表示周日
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// parseCron parses a cron expression evaluated in the location
+func parseCron(expr string, location *time.Location) (*cronSpec, error) {
+	if location == nil {
+		location = time.Local
+	}
+	fields := strings.Fields(expr)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "CRON_TZ=") || strings.HasPrefix(fields[0], "TZ=")) {
+		loc, err := time.LoadLocation(fields[0][strings.Index(fields[0], "=")+1:])
+		if err != nil {
+			return nil, fmt.Errorf("cron: %v", err)
+		}
+		location, fields = loc, fields[1:]
+	}
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		descriptor, ok := cronDescriptors[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("cron: unknown descriptor %s", fields[0])
+		}
+		fields = strings.Fields(descriptor)
+	}
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...) // 默认第0秒
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: %q must have 5 or 6 fields", expr)
+	}
+	spec := &cronSpec{location: location}
+	var err error
+	for i, field := range []struct {
+		bits *uint64
+		spec cronField
+	}{{&spec.second, cronSeconds}, {&spec.minute, cronMinutes}, {&spec.hour, cronHours},
+		{&spec.dom, cronDom}, {&spec.month, cronMonths}, {&spec.dow, cronDow}} {
+		if *field.bits, err = parseCronField(fields[i], field.spec); err != nil {
+			return nil, fmt.Errorf("cron: %q: %v", expr, err)
+		}
+	}
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1 // 7 为周日
+	}
+	spec.anyDay = isCronWildcard(fields[3]) || isCronWildcard(fields[5])
+	return spec, nil
+}
+
+func isCronWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			stepped = true
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %s", part)
+			}
+			part = part[:i]
+		}
+		low, high := spec.min, spec.max
+		switch {
+		case isCronWildcard(part):
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = spec.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if high, err = spec.value(bounds[1]); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := spec.value(part)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if !stepped {
+				high = value // "5/10" 表示从5开始
+			}
+		}
+		if low > high {
+			return 0, fmt.Errorf("invalid range %s", part)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or a name of the field
+func (spec cronField) value(s string) (int, error) {
+	for i, name := range spec.names {
+		if strings.EqualFold(s, name) {
+			return spec.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < spec.min || v > spec.max {
+		return 0, fmt.Errorf("invalid value %s, expected %d-%d", s, spec.min, spec.max)
+	}
+	return v, nil
+}
+
+func (spec *cronSpec) dayMatches(t time.Time) bool {
+	dom := spec.dom&(1<<uint(t.Day())) != 0
+	dow := spec.dow&(1<<uint(t.Weekday())) != 0
+	if spec.anyDay {
+		return dom && dow
+	}
+	return dom || dow // 两者都受限时，满足其一即可
+}
+
+// after returns the first run time of the spec after t, zero if there is none within 5 years
+func (spec *cronSpec) after(t time.Time) time.Time {
+	loc := spec.location
+	t = t.In(loc).Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + 5
+	for t.Year() <= limit {
+		if spec.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !spec.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if spec.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(time.Hour)
+			continue
+		}
+		if spec.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if spec.second&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,109 @@
+package EventBus_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestScheduleNext(t *testing.T) {
+	bus := EventBus.New().(*EventBus.EventBus)
+	defer bus.Close(context.Background())
+
+	yearly, err := bus.Schedule("CRON_TZ=UTC @yearly", "new-year", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := yearly.Next()
+	if next.Year() != time.Now().UTC().Year()+1 || next.Month() != time.January || next.Day() != 1 || next.Hour() != 0 {
+		t.Fatal(next)
+	}
+
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	workday, err := bus.Schedule("30 9 * * MON-FRI", "standup", nil, EventBus.WithLocation(tokyo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next = workday.Next().In(tokyo)
+	if next.Hour() != 9 || next.Minute() != 30 || next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+		t.Fatal(next)
+	}
+
+	// day of month and day of week both restricted: either matches
+	either, _ := bus.Schedule("0 0 13 * 5", "friday-or-13th", nil, EventBus.WithLocation(time.UTC))
+	if next := either.Next().UTC(); next.Day() != 13 && next.Weekday() != time.Friday {
+		t.Fatal(next)
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "@every", "0 0 30 2 *"} {
+		if _, err := bus.Schedule(expr, "invalid", nil); err == nil {
+			t.Fatal(expr)
+		}
+	}
+
+	entries := bus.Schedules()
+	if len(entries) != 3 || entries[0].ID() != yearly.ID() || entries[1].Spec() != "30 9 * * MON-FRI" {
+		t.Fatal(entries)
+	}
+	if !bus.Unschedule(workday.ID()) || bus.Unschedule(workday.ID()) || len(bus.Schedules()) != 2 {
+		t.Fail()
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	bus := EventBus.New().(*EventBus.EventBus)
+	received := make(chan int, 10)
+	bus.Subscribe("tick", func(n int) { received <- n })
+	var count int32
+	entry, err := bus.Schedule("* * * * * *", "tick", func() []interface{} {
+		return []interface{}{int(atomic.AddInt32(&count, 1))}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case n := <-received:
+		if n != 1 {
+			t.Fatal(n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("not published")
+	}
+	bus.Close(context.Background())
+	if !entry.Next().IsZero() || len(bus.Schedules()) != 0 {
+		t.Fail()
+	}
+	if _, err := bus.Schedule("* * * * * *", "tick", nil); err != EventBus.ErrBusClosed {
+		t.Fatal(err)
+	}
+}
+
+func TestScheduleMissedRuns(t *testing.T) {
+	bus := EventBus.New().(*EventBus.EventBus)
+	defer bus.Close(context.Background())
+	var blocked, all, once, skip int32
+	bus.Subscribe("block", func() {
+		if atomic.AddInt32(&blocked, 1) == 1 {
+			time.Sleep(2100 * time.Millisecond) // blocks the scheduler during the next two runs
+		}
+	})
+	bus.Subscribe("all", func() { atomic.AddInt32(&all, 1) })
+	bus.Subscribe("once", func() { atomic.AddInt32(&once, 1) })
+	bus.Subscribe("skip", func() { atomic.AddInt32(&skip, 1) })
+
+	bus.Schedule("* * * * * *", "block", nil)
+	bus.Schedule("* * * * * *", "all", nil, EventBus.WithMissedRuns(EventBus.MissedRunAll))
+	bus.Schedule("* * * * * *", "once", nil)
+	bus.Schedule("* * * * * *", "skip", nil, EventBus.WithMissedRuns(EventBus.MissedRunSkip))
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&once) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if a, o, s := atomic.LoadInt32(&all), atomic.LoadInt32(&once), atomic.LoadInt32(&skip); a != 3 || o != 2 || s != 1 {
+		t.Fatal(a, o, s)
+	}
+}
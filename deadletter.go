@@ -0,0 +1,76 @@
+package EventBus
+
+import (
+	"context"
+	"sync"
+)
+
+// DeadReason tells why an event became a dead letter
+type DeadReason int
+
+const (
+	DeadNoSubscribers     DeadReason = iota // no handler is subscribed to the topic
+	DeadSignatureMismatch                   // no handler accepts the arguments of the event
+	DeadHandlerError                        // a handler returned an error
+	DeadPanic                               // a handler panicked
+)
+
+func (r DeadReason) String() string {
+	switch r {
+	case DeadNoSubscribers:
+		return "no subscribers"
+	case DeadSignatureMismatch:
+		return "signature mismatch"
+	case DeadHandlerError:
+		return "handler error"
+	case DeadPanic:
+		return "panic"
+	}
+	return "unknown"
+}
+
+// DeadLetter is an event which was not handled, or whose handler failed
+type DeadLetter struct {
+	Topic        string // published topic
+	Args         []interface{}
+	Reason       DeadReason
+	Err          error        // error of the handler, *PanicError for a panic
+	Subscription Subscription // failed handler, nil for DeadNoSubscribers and DeadSignatureMismatch
+}
+
+// DeadLetterHandler receives the dead letters of the bus
+type DeadLetterHandler func(letter *DeadLetter)
+
+// WithDeadLetterTopic publishes the dead letters to the topic, as a single *DeadLetter argument.
+// The events of the dead-letter topic itself never become dead letters.
+func WithDeadLetterTopic(topic string) BusOption {
+	return func(bus *EventBus) {
+		bus.deadTopic = topic
+	}
+}
+
+// WithDeadLetterHandler passes the dead letters to the handler
+func WithDeadLetterHandler(handler DeadLetterHandler) BusOption {
+	return func(bus *EventBus) {
+		bus.deadHandler = handler
+	}
+}
+
+// deadLetter passes the event to the dead-letter handler and topic, if any
+func (bus *EventBus) deadLetter(topic string, args []interface{}, reason DeadReason, err error, handler *eventHandler) {
+	if bus.deadHandler == nil && bus.deadTopic == "" || topic == bus.deadTopic {
+		return
+	}
+	letter := &DeadLetter{Topic: topic, Args: args, Reason: reason, Err: err}
+	if handler != nil {
+		letter.Subscription = handler
+	}
+	if bus.deadHandler != nil {
+		bus.deadHandler(letter)
+	}
+	if bus.deadTopic != "" {
+		for _, err := range bus.publish(context.Background(), &sync.WaitGroup{}, false, bus.deadTopic, letter) {
+			bus.handleError(bus.deadTopic, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package EventBus_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestDeadLetterHandler(t *testing.T) {
+	letters := []*EventBus.DeadLetter{}
+	bus := EventBus.New(EventBus.WithDeadLetterHandler(func(letter *EventBus.DeadLetter) {
+		letters = append(letters, letter)
+	}), EventBus.WithPanicHandler(func(string, interface{}, interface{}, []byte) {}))
+
+	bus.Publish("nobody", 1)
+	bus.Subscribe("typed", func(s string) {})
+	bus.Publish("typed", 1)
+	bus.Publish("typed", "ok")
+	failure := errors.New("failure")
+	sub, _ := bus.SubscribeHandle("failing", func() error { return failure })
+	bus.Publish("failing")
+	bus.Subscribe("panicking", func() { panic("boom") })
+	bus.Publish("panicking")
+
+	if len(letters) != 4 {
+		t.Fatal(len(letters))
+	}
+	if letters[0].Reason != EventBus.DeadNoSubscribers || letters[0].Topic != "nobody" || letters[0].Args[0] != 1 {
+		t.Fatal(letters[0])
+	}
+	if letters[1].Reason != EventBus.DeadSignatureMismatch || letters[1].Subscription != nil {
+		t.Fatal(letters[1])
+	}
+	if letters[2].Reason != EventBus.DeadHandlerError || letters[2].Err != failure || letters[2].Subscription != sub {
+		t.Fatal(letters[2])
+	}
+	if _, ok := letters[3].Err.(*EventBus.PanicError); letters[3].Reason != EventBus.DeadPanic || !ok {
+		t.Fatal(letters[3])
+	}
+}
+
+func TestDeadLetterTopic(t *testing.T) {
+	bus := EventBus.New(EventBus.WithDeadLetterTopic("dead"))
+	var lock sync.Mutex
+	reasons := []EventBus.DeadReason{}
+	bus.Subscribe("dead", func(letter *EventBus.DeadLetter) {
+		lock.Lock()
+		defer lock.Unlock()
+		reasons = append(reasons, letter.Reason)
+	})
+	bus.SubscribeAsync("async", func() error { return errors.New("failure") }, false)
+	bus.PublishWaitAsync("async").Wait()
+	bus.Publish("nobody")
+	// events of the dead-letter topic never become dead letters
+	bus.Publish("dead", "mismatch")
+
+	if len(reasons) != 2 || reasons[0] != EventBus.DeadHandlerError || reasons[1] != EventBus.DeadNoSubscribers {
+		t.Fatal(reasons)
+	}
+	if EventBus.DeadSignatureMismatch.String() != "signature mismatch" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,140 @@
+package EventBus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is the envelope of a published event.
+// A handler whose first parameter, after an optional context.Context, is an *Event receives
+// the envelope before the arguments of the event. Every handler receives its own copy of the envelope,
+// its changes, e.g. to Headers, are not seen by the other handlers nor by the retained and history replays.
+type Event struct {
+	ID        string            // unique identifier of the event
+	Topic     string            // published topic
+	Timestamp time.Time         // time of the publish
+	Source    string            // publisher of the event, see WithSource
+	Headers   map[string]string // metadata of the event
+	Payload   []interface{}     // arguments of the publisher
+}
+
+var (
+	eventType     = reflect.TypeOf((*Event)(nil))
+	eventIDPrefix = newEventIDPrefix()
+	eventSequence uint64
+)
+
+// newEventIDPrefix returns a random prefix, the identifiers of the events of different processes differ
+func newEventIDPrefix() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+func newEventID() string {
+	return eventIDPrefix + "-" + strconv.FormatUint(atomic.AddUint64(&eventSequence, 1), 10)
+}
+
+// WithSource sets the source of the events published by the bus
+func WithSource(source string) BusOption {
+	return func(bus *EventBus) {
+		bus.source = source
+	}
+}
+
+// newEvent returns the envelope of an event published now
+func (bus *EventBus) newEvent(topic string, args []interface{}) *Event {
+	return &Event{ID: newEventID(), Topic: topic, Timestamp: time.Now(), Source: bus.source, Payload: args}
+}
+
+// clone returns a copy of the envelope with its own headers and payload slice
+func (e *Event) clone() *Event {
+	event := *e
+	if e.Headers != nil {
+		event.Headers = make(map[string]string, len(e.Headers))
+		for k, v := range e.Headers {
+			event.Headers[k] = v
+		}
+	}
+	if e.Payload != nil {
+		event.Payload = append([]interface{}(nil), e.Payload...)
+	}
+	return &event
+}
+
+// with returns a copy of the envelope with another topic and payload
+func (e *Event) with(topic string, args []interface{}) *Event {
+	event := *e
+	event.Topic, event.Payload = topic, args
+	return &event
+}
+
+// PublishEvent publishes the payload of the envelope to its topic, like PublishContext.
+// The ID, Timestamp and Source of the envelope are set if they are empty,
+// the handlers taking the envelope receive it with its headers.
+func (bus *EventBus) PublishEvent(ctx context.Context, event *Event) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return bus.publishEnvelope(ctx, event.Topic, event)
+}
+
+// publishEnvelope publishes the payload of the envelope to the topic, which may differ from the topic
+// of the envelope, e.g. for a remote event delivered to the pattern subscribed by a client
+func (bus *EventBus) publishEnvelope(ctx context.Context, topic string, event *Event) error {
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.Source == "" {
+		event.Source = bus.source
+	}
+	errs := bus.publishEvent(ctx, &sync.WaitGroup{}, false, event, topic, event.Payload)
+	if len(errs) > 0 {
+		return NewMultiError(&errs)
+	}
+	return nil
+}
+
+// takesEnvelope returns true if the first parameter of the handler, after an optional context, is an *Event
+func takesEnvelope(funcType reflect.Type) bool {
+	i := 0
+	if funcType.NumIn() > 0 && funcType.In(0) == contextType {
+		i = 1
+	}
+	if funcType.IsVariadic() && i == funcType.NumIn()-1 {
+		return false // 可变参数
+	}
+	return funcType.NumIn() > i && funcType.In(i) == eventType
+}
+
+// wantsEnvelope returns true if one of the handlers takes the envelope
+func wantsEnvelope(handlers []*eventHandler) bool {
+	for _, handler := range handlers {
+		if handler.envelope {
+			return true
+		}
+	}
+	return false
+}
+
+// envelopeArgs inserts the envelope before args, after a context passed by the publisher
+func envelopeArgs(event *Event, args []interface{}) []interface{} {
+	values := make([]interface{}, 0, len(args)+1)
+	if isContextArg(args) {
+		values = append(values, args[0])
+		args = args[1:]
+	}
+	values = append(values, event)
+	return append(values, args...)
+}
@@ -1,9 +1,20 @@
 package EventBus
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"reflect"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
 )
 
 //BusSubscriber defines subscription-related bus behavior
@@ -12,19 +23,36 @@ type BusSubscriber interface {
 	SubscribeAsync(topic string, fn interface{}, transactional bool) error
 	SubscribeOnce(topic string, fn interface{}) error
 	SubscribeOnceAsync(topic string, fn interface{}) error
+	SubscribeWithOptions(topic string, fn interface{}, opts ...SubscribeOption) (Subscription, error)
+	SubscribeHandle(topic string, fn interface{}) (Subscription, error)
+	SubscribeAsyncHandle(topic string, fn interface{}, transactional bool) (Subscription, error)
+	SubscribeOnceHandle(topic string, fn interface{}) (Subscription, error)
+	SubscribeOnceAsyncHandle(topic string, fn interface{}) (Subscription, error)
+	SubscribeFrom(topic string, fn interface{}, since time.Time, opts ...SubscribeOption) (Subscription, error)
+	SubscribeInBatches(topic string, fn func([]Event), maxSize int, maxWait time.Duration, opts ...SubscribeOption) (Subscription, error)
 	Unsubscribe(topic string, handler interface{}) error
 }
 
 //BusPublisher defines publishing-related bus behavior
 type BusPublisher interface {
 	Publish(topic string, args ...interface{})
+	PublishE(topic string, args ...interface{}) error
+	PublishContext(ctx context.Context, topic string, args ...interface{}) error
 	PublishWaitAsync(topic string, args ...interface{}) *sync.WaitGroup
+	PublishRetained(topic string, args ...interface{})
+	PublishEvent(ctx context.Context, event *Event) error
+	PublishAfter(d time.Duration, topic string, args ...interface{}) ScheduledEvent
+	PublishAt(t time.Time, topic string, args ...interface{}) ScheduledEvent
+	PublishEvery(period time.Duration, topic string, fnArgs func() []interface{}) ScheduledEvent
 }
 
 //BusController defines bus control behavior (checking handler's presence, synchronization)
 type BusController interface {
 	HasCallback(topic string) bool
 	WaitAsync(wg *sync.WaitGroup)
+	ClearRetained(topic string)
+	Drain(ctx context.Context) error
+	Close(ctx context.Context) error
 }
 
 //Bus englobes global (subscribe, publish, control) bus behavior
@@ -32,16 +60,63 @@ type Bus interface {
 	BusController
 	BusSubscriber
 	BusPublisher
+	BusRequester
 }
 
 // EventBus - box for handlers and callbacks.
+// Topics are hierarchical, levels are separated by ":" and subscriptions may use
+// wildcards: "*" matches exactly one level, "#" matches zero or more levels.
 type EventBus struct {
-	handlers map[string][]*eventHandler
-	lock     sync.RWMutex // a lock for the map
+	handlers atomic.Value // *topicTrie, replaced on every change and read without lock
+	lock     sync.Mutex   // a lock for changing the handlers
+	sequence uint64       // subscription order of handlers
+
+	errorHandler ErrorHandler
+	panicHandler PanicHandler
+	deadHandler  DeadLetterHandler
+	deadTopic    string
+	source       string                    // source of the events, see WithSource
+	strict       bool                      // see WithStrictArguments
+	convert      bool                      // see WithArgumentConversion
+	schemas      map[string][]reflect.Type // see DeclareTopic
+	pool         *workerPool               // runs async callbacks, nil: a goroutine per callback
+	running      tracker                   // async callbacks in flight
+	publishing   inflight                  // publishes in progress, see Close
+	responders   sync.Map                  // topic -> *eventHandler, see Respond
+	middleware   atomic.Value              // []Middleware, see Use
+	hooks        atomic.Value              // []PublishHook, see OnPublish
+	retainLock   sync.Mutex                // orders stored publishes with replaying subscriptions
+	retained     map[string]*storedEvent
+	retainSeq    uint64
+	historyCfg   []historyConfig // see WithHistory, set by New
+	history      map[string]*eventRing
+	scheduler    scheduler // publishes the events of PublishAfter, PublishAt and PublishEvery
+	batchers     sync.Map  // *eventHandler -> *batcher, flushed by Close
+	closed       int32
+	quit         chan struct{} // closed by Close
 }
 
 type eventHandler struct {
+	stats         handlerStats
+	bus           *EventBus
+	active        int32 // 1 while subscribed
+	fired         int32 // 1 once a once handler is called
+	id            uint64
+	priority      int    // handlers with a higher priority run first
+	topic         string // topic or pattern subscribed to
+	responder     bool   // registered with Respond
 	callBack      reflect.Value
+	typed         typedHandler   // called without reflection, see Subscribe[T]
+	middleware    []Middleware   // runs inside the middleware of the bus
+	retry         *RetryPolicy   // retries the failed async callbacks
+	limiter       limiter        // debounces, throttles or rate limits the events
+	batch         *batcher       // collects the events, see SubscribeInBatches
+	envelope      bool           // receives the *Event before the arguments
+	ordering      *orderedQueues // orders the async callbacks per key, see WithOrderingKey
+	replay        bool           // replay the retained events on subscribe
+	history       bool           // replay the history on subscribe, see SubscribeFrom
+	since         time.Time      // oldest event of the history to replay
+	gate          *replayGate    // holds back the events published while replaying
 	flagOnce      bool
 	async         bool
 	transactional bool
@@ -49,31 +124,99 @@ type eventHandler struct {
 }
 
 // New returns new EventBus with empty handlers.
-func New() Bus {
-	b := &EventBus{
-		make(map[string][]*eventHandler),
-		sync.RWMutex{},
+func New(opts ...BusOption) Bus {
+	b := &EventBus{quit: make(chan struct{})}
+	for _, opt := range opts {
+		opt(b)
 	}
 	return Bus(b)
 }
 
 // doSubscribe handles the subscription logic and is utilized by the public Subscribe functions
-func (bus *EventBus) doSubscribe(topic string, fn interface{}, handler *eventHandler) error {
+func (bus *EventBus) doSubscribe(topic string, fn interface{}, handler *eventHandler) (Subscription, error) {
+	if handler.replay || handler.history {
+		return bus.subscribeReplay(topic, fn, handler)
+	}
+	return bus.addHandler(topic, fn, handler)
+}
+
+// addHandler adds the handler to the snapshot of the handlers
+func (bus *EventBus) addHandler(topic string, fn interface{}, handler *eventHandler) (Subscription, error) {
 	bus.lock.Lock()
 	defer bus.lock.Unlock()
+	if bus.Closed() {
+		return nil, ErrBusClosed
+	}
 	if !(reflect.TypeOf(fn).Kind() == reflect.Func) {
-		return fmt.Errorf("%s is not of type reflect.Func", reflect.TypeOf(fn).Kind())
+		return nil, fmt.Errorf("%s is not of type reflect.Func", reflect.TypeOf(fn).Kind())
 	}
-	bus.handlers[topic] = append(bus.handlers[topic], handler)
-	return nil
+	if handler.batch == nil { // 批量处理器接收 []Event，不检查声明的参数
+		if err := bus.checkDeclared(topic, fn); err != nil {
+			return nil, err
+		}
+	}
+	bus.sequence++
+	handler.id = bus.sequence
+	handler.envelope = handler.typed == nil && handler.batch == nil && takesEnvelope(reflect.TypeOf(fn))
+	handler.topic = topic
+	handler.bus = bus
+	handler.active = 1
+	bus.handlers.Store(bus.topics().add(topic, handler))
+	return handler, nil
+}
+
+// topics returns the current snapshot of the handlers
+func (bus *EventBus) topics() *topicTrie {
+	if trie, ok := bus.handlers.Load().(*topicTrie); ok {
+		return trie
+	}
+	return newTopicTrie() // zero EventBus
+}
+
+// removeHandler removes the handler from the bus, returns false if it was already removed
+func (bus *EventBus) removeHandler(handler *eventHandler) bool {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+	return bus.doRemoveHandler(handler)
+}
+
+func (bus *EventBus) doRemoveHandler(handler *eventHandler) bool {
+	if handler.responder {
+		return bus.removeResponder(handler)
+	}
+	trie := bus.topics().remove(handler.topic, handler)
+	if trie == nil {
+		return false
+	}
+	if handler.batch != nil {
+		bus.batchers.Delete(handler)
+		handler.batch.stop()
+	}
+	bus.handlers.Store(trie)
+	atomic.StoreInt32(&handler.active, 0)
+	return true
 }
 
 // Subscribe subscribes to a topic.
 // Returns error if `fn` is not a function.
 func (bus *EventBus) Subscribe(topic string, fn interface{}) error {
-	return bus.doSubscribe(topic, fn, &eventHandler{
-		reflect.ValueOf(fn), false, false, false, sync.Mutex{},
-	})
+	_, err := bus.SubscribeHandle(topic, fn)
+	return err
+}
+
+// SubscribeHandle works like Subscribe and returns the handle of the subscription.
+func (bus *EventBus) SubscribeHandle(topic string, fn interface{}) (Subscription, error) {
+	return bus.SubscribeWithOptions(topic, fn)
+}
+
+// SubscribeWithOptions subscribes to a topic, the options configure how and when the callback is run.
+// Returns error if `fn` is not a function.
+func (bus *EventBus) SubscribeWithOptions(topic string, fn interface{}, opts ...SubscribeOption) (Subscription, error) {
+	handler := &eventHandler{callBack: reflect.ValueOf(fn)}
+	for _, opt := range opts {
+		opt(handler)
+	}
+	return bus.doSubscribe(topic, fn, handler)
 }
 
 // SubscribeAsync subscribes to a topic with an asynchronous callback
@@ -81,163 +224,437 @@ func (bus *EventBus) Subscribe(topic string, fn interface{}) error {
 // run serially (true) or concurrently (false)
 // Returns error if `fn` is not a function.
 func (bus *EventBus) SubscribeAsync(topic string, fn interface{}, transactional bool) error {
-	return bus.doSubscribe(topic, fn, &eventHandler{
-		reflect.ValueOf(fn), false, true, transactional, sync.Mutex{},
-	})
+	_, err := bus.SubscribeAsyncHandle(topic, fn, transactional)
+	return err
+}
+
+// SubscribeAsyncHandle works like SubscribeAsync and returns the handle of the subscription.
+func (bus *EventBus) SubscribeAsyncHandle(topic string, fn interface{}, transactional bool) (Subscription, error) {
+	return bus.SubscribeWithOptions(topic, fn, WithAsync(transactional))
 }
 
 // SubscribeOnce subscribes to a topic once. Handler will be removed after executing.
 // Returns error if `fn` is not a function.
 func (bus *EventBus) SubscribeOnce(topic string, fn interface{}) error {
-	return bus.doSubscribe(topic, fn, &eventHandler{
-		reflect.ValueOf(fn), true, false, false, sync.Mutex{},
-	})
+	_, err := bus.SubscribeOnceHandle(topic, fn)
+	return err
+}
+
+// SubscribeOnceHandle works like SubscribeOnce and returns the handle of the subscription.
+func (bus *EventBus) SubscribeOnceHandle(topic string, fn interface{}) (Subscription, error) {
+	return bus.SubscribeWithOptions(topic, fn, WithOnce())
 }
 
 // SubscribeOnceAsync subscribes to a topic once with an asynchronous callback
 // Handler will be removed after executing.
 // Returns error if `fn` is not a function.
 func (bus *EventBus) SubscribeOnceAsync(topic string, fn interface{}) error {
-	return bus.doSubscribe(topic, fn, &eventHandler{
-		reflect.ValueOf(fn), true, true, false, sync.Mutex{},
-	})
+	_, err := bus.SubscribeOnceAsyncHandle(topic, fn)
+	return err
 }
 
-// HasCallback returns true if exists any callback subscribed to the topic.
+// SubscribeOnceAsyncHandle works like SubscribeOnceAsync and returns the handle of the subscription.
+func (bus *EventBus) SubscribeOnceAsyncHandle(topic string, fn interface{}) (Subscription, error) {
+	return bus.SubscribeWithOptions(topic, fn, WithOnce(), WithAsync(false))
+}
+
+// HasCallback returns true if exists any callback subscribed to the topic,
+// either to the topic itself or to a pattern matching the topic.
 func (bus *EventBus) HasCallback(topic string) bool {
-	bus.lock.RLock()
-	defer bus.lock.RUnlock()
-	_, ok := bus.handlers[topic]
-	if ok {
-		return len(bus.handlers[topic]) > 0
-	}
-	return false
+	trie := bus.topics()
+	return len(trie.lookup(topic)) > 0 || len(trie.match(topic)) > 0
 }
 
 // Unsubscribe removes callback defined for a topic.
+// The topic must be the same topic or pattern used to subscribe.
 // Returns error if there are no callbacks subscribed to the topic.
 func (bus *EventBus) Unsubscribe(topic string, handler interface{}) error {
 	bus.lock.Lock()
 	defer bus.lock.Unlock()
-	if _, ok := bus.handlers[topic]; ok && len(bus.handlers[topic]) > 0 {
-		bus.removeHandler(topic, bus.findHandlerIdx(topic, reflect.ValueOf(handler)))
+	if len(bus.topics().lookup(topic)) > 0 {
+		if h := bus.findHandler(topic, reflect.ValueOf(handler)); h != nil {
+			bus.doRemoveHandler(h)
+		}
 		return nil
 	}
 	return fmt.Errorf("topic %s doesn't exist", topic)
 }
 
 // Publish executes callback defined for a topic. Any additional argument will be transferred to the callback.
+// Errors returned by the callbacks are passed to the error handler of the bus.
 func (bus *EventBus) Publish(topic string, args ...interface{}) {
 	// bus.lock.Lock() // will unlock if handler is not found or always after setUpPublish
 	// defer bus.lock.Unlock()
 	bus.PublishWaitAsync(topic, args...)
 }
 
+// PublishE works like Publish, but returns a *MultiError with the errors returned by the sync callbacks,
+// a panicking sync callback is reported as *PanicError.
+// Errors of async callbacks are passed to the error handler of the bus.
+func (bus *EventBus) PublishE(topic string, args ...interface{}) error {
+	return bus.PublishContext(context.Background(), topic, args...)
+}
+
+// PublishContext works like PublishE, callbacks whose first parameter is a context.Context receive ctx.
+// Once ctx is done, the remaining sync callbacks are skipped and ctx.Err() is returned,
+// async callbacks which have not started yet are skipped.
+func (bus *EventBus) PublishContext(ctx context.Context, topic string, args ...interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	errs := bus.publish(ctx, &sync.WaitGroup{}, false, topic, args...)
+	if len(errs) > 0 {
+		return NewMultiError(&errs)
+	}
+	return nil
+}
+
 // Publish executes callback defined for a topic. Any additional argument will be transferred to the callback.
 func (bus *EventBus) PublishWaitAsync(topic string, args ...interface{}) *sync.WaitGroup {
-	// bus.lock.RLock() // will unlock if handler is not found or always after setUpPublish
-	// defer bus.lock.RUnlock() // 执行once handler， 无法确定有多少个读锁，所以通过copy方式解决多线程处理问题
 	wg := &sync.WaitGroup{} // 同步锁
-	if handlers, ok := bus.handlers[topic]; ok && 0 < len(handlers) {
-		// Handlers slice may be changed by removeHandler and Unsubscribe during iteration,
-		// so make a copy and iterate the copied slice.
-		copyHandlers := make([]*eventHandler, len(handlers))
-		copy(copyHandlers, handlers)
-		for i, handler := range copyHandlers {
-			arguments, ok := bus.PassedArguments(handler.callBack.Type(), args...)
-			if !ok {
-				continue // 参数类型不匹配
-			}
-			if handler.flagOnce {
-				bus.lock.Lock()             // 加锁map
-				bus.removeHandler(topic, i) // 修改map， Unsubscribe(topic, handler)
-				bus.lock.Unlock()           // 解锁map
+	for _, err := range bus.publish(context.Background(), wg, false, topic, args...) {
+		bus.handleError(topic, err)
+	}
+	return wg
+}
+
+// publish dispatches the event and returns the errors of the sync callbacks.
+// A retained event, or an event of a topic with history, is stored for the handlers subscribed later.
+func (bus *EventBus) publish(ctx context.Context, wg *sync.WaitGroup, retain bool, topic string, args ...interface{}) []error {
+	return bus.publishEvent(ctx, wg, retain, nil, topic, args)
+}
+
+// publishEvent works like publish, the envelope of the event is created if it is nil and needed
+func (bus *EventBus) publishEvent(ctx context.Context, wg *sync.WaitGroup, retain bool, event *Event, topic string, args []interface{}) []error {
+	bus.publishing.enter() // 在检查 Closed 之前，Close 等待进行中的发布
+	defer bus.publishing.leave()
+	if bus.Closed() {
+		return []error{ErrBusClosed}
+	}
+	if len(bus.publishHooks()) > 0 {
+		pub, err := bus.beforePublish(&Publication{Ctx: ctx, Topic: topic, Args: args})
+		if err != nil {
+			return []error{err} // 拒绝发布
+		}
+		ctx, topic, args = pub.Ctx, pub.Topic, pub.Args
+		if event != nil {
+			event = event.with(topic, args) // 钩子可能修改了事件
+		}
+	}
+	// 读取快照，无需加锁; match returns a new slice of the snapshot, which is never modified.
+	// A handler unsubscribed during the iteration is skipped, a handler subscribed is not called.
+	var handlers []*eventHandler
+	if history := bus.historyOf(topic); retain || history != nil {
+		if event == nil {
+			event = bus.newEvent(topic, args)
+		}
+		handlers = bus.store(topic, event, retain, history)
+	} else {
+		handlers = bus.topics().match(topic)
+	}
+	if event == nil && wantsEnvelope(handlers) {
+		event = bus.newEvent(topic, args)
+	}
+	if len(handlers) == 0 {
+		bus.deadLetter(topic, args, DeadNoSubscribers, nil, nil)
+		return nil
+	}
+	var errs []error
+	mismatched := 0
+	for _, handler := range handlers {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err) // 上下文已结束，停止分发
+			return errs
+		}
+		if handler.gate != nil {
+			wg.Add(1) // 发布者等待延后的分发
+			if handler.gate.hold(bus.deferred(ctx, wg, handler, event, topic, args)) {
+				continue // 等待重放完成
 			}
-			if !handler.async {
-				handler.callBack.Call(arguments)
-			} else {
-				wg.Add(1)
-				if handler.transactional {
-					handler.Lock()
-				}
-				go bus.doPublishAsync(wg, handler, arguments)
+			wg.Done()
+		}
+		err := bus.dispatch(ctx, wg, handler, event, topic, args)
+		if errors.Is(err, ErrArgumentMismatch) {
+			mismatched++
+		}
+		if errors.Is(err, ErrStopPropagation) {
+			return errs // 停止执行后续的处理器
+		} else if err != nil && err != ErrArgumentMismatch {
+			errs = append(errs, err)
+		}
+	}
+	if mismatched == len(handlers) {
+		bus.deadLetter(topic, args, DeadSignatureMismatch, ErrArgumentMismatch, nil)
+	}
+	return errs
+}
+
+// dispatch runs a sync handler, or submits an async handler, if it accepts args.
+// Returns ErrArgumentMismatch, or a *SignatureError in strict mode, if the handler does not accept args.
+func (bus *EventBus) dispatch(ctx context.Context, wg *sync.WaitGroup, handler *eventHandler, event *Event, topic string, args []interface{}) error {
+	arguments, ok := bus.arguments(ctx, handler, event, args)
+	if !ok {
+		return bus.mismatch(topic, handler, args) // 参数类型不匹配
+	}
+	if !handler.Active() {
+		return nil // 已经取消订阅
+	}
+	if handler.flagOnce {
+		if !atomic.CompareAndSwapInt32(&handler.fired, 0, 1) {
+			return nil // 已经被其他发布执行
+		}
+		bus.removeHandler(handler) // Unsubscribe(handler.topic, handler)
+	}
+	if handler.limiter != nil && !handler.limiter.admit(topic, func() {
+		ctx := detach(ctx) // 延后的交付不受发布者取消的影响
+		arguments, _ := bus.arguments(ctx, handler, event, args)
+		if err := bus.deliver(ctx, &sync.WaitGroup{}, handler, event, topic, arguments, args); err != nil {
+			bus.handleError(topic, err)
+		}
+	}) {
+		return nil // 延后或者丢弃
+	}
+	return bus.deliver(ctx, wg, handler, event, topic, arguments, args)
+}
+
+// deliver runs a sync handler, or submits an async handler, with the matched arguments
+func (bus *EventBus) deliver(ctx context.Context, wg *sync.WaitGroup, handler *eventHandler, event *Event, topic string, arguments []reflect.Value, args []interface{}) error {
+	if handler.batch != nil {
+		if event == nil {
+			event = bus.newEvent(topic, args)
+		}
+		handler.batch.add(*event.clone())
+		return nil
+	}
+	if !handler.async {
+		return bus.invoke(topic, handler, arguments, args)
+	}
+	wg.Add(1)
+	if handler.transactional {
+		handler.Lock()
+	}
+	call := bus.running.add(topic, handler)
+	task := asyncTask{
+		run: func() {
+			defer bus.running.done(call)
+			bus.doPublishAsync(ctx, wg, topic, handler, arguments, args)
+		},
+		drop: func() {
+			if handler.transactional {
+				handler.Unlock()
 			}
+			bus.running.done(call)
+			wg.Done()
+		},
+	}
+	if handler.ordering != nil {
+		return handler.ordering.submit(bus, topic, args, task)
+	}
+	return bus.runAsync(task)
+}
+
+// deferred returns the dispatch of an event held back while the handler replays,
+// its errors are passed to the error handler.
+func (bus *EventBus) deferred(ctx context.Context, wg *sync.WaitGroup, handler *eventHandler, event *Event, topic string, args []interface{}) func() {
+	return func() {
+		defer wg.Done()
+		if err := bus.dispatch(ctx, wg, handler, event, topic, args); err != nil {
+			bus.handleError(topic, err)
 		}
 	}
-	return wg
 }
 
-func (bus *EventBus) doPublishAsync(wg *sync.WaitGroup, handler *eventHandler, arguments []reflect.Value) {
+func (bus *EventBus) doPublishAsync(ctx context.Context, wg *sync.WaitGroup, topic string, handler *eventHandler, arguments []reflect.Value, args []interface{}) {
 	defer wg.Done()
 	if handler.transactional {
 		defer handler.Unlock()
 	}
-	handler.callBack.Call(arguments)
+	if ctx.Err() != nil {
+		return // 上下文已结束，跳过
+	}
+	var err error
+	if handler.retry != nil {
+		err = bus.invokeRetry(ctx, topic, handler, arguments, args)
+	} else {
+		err = bus.invoke(topic, handler, arguments, args)
+	}
+	if err != nil {
+		bus.handleError(topic, err)
+	}
 }
 
-func (bus *EventBus) removeHandler(topic string, idx int) {
-	if _, ok := bus.handlers[topic]; !ok {
-		return
+// arguments matches args with the parameters of the handler, typed handlers take args as they are.
+// A handler taking the envelope receives event before args.
+func (bus *EventBus) arguments(ctx context.Context, handler *eventHandler, event *Event, args []interface{}) ([]reflect.Value, bool) {
+	if handler.batch != nil {
+		return nil, true // 批量处理器接收任意参数
+	}
+	if handler.typed != nil {
+		return nil, handler.typed.accept(args)
+	}
+	if handler.envelope {
+		args = envelopeArgs(event.clone(), args)
+	}
+	return bus.PassedArgumentsContext(ctx, handler.callBack.Type(), args...)
+}
+
+// invoke calls the handler and returns its error, a panic is recovered and returned as *PanicError.
+// Typed handlers are called with args, the other handlers with the matched arguments.
+// Failed invocations are passed to the dead-letter sink of the bus.
+func (bus *EventBus) invoke(topic string, handler *eventHandler, arguments []reflect.Value, args []interface{}) error {
+	_, err := bus.call(topic, handler, arguments, args)
+	bus.failed(topic, handler, args, err)
+	return err
+}
+
+// failed passes a failed invocation to the dead-letter sink
+func (bus *EventBus) failed(topic string, handler *eventHandler, args []interface{}, err error) {
+	if _, ok := err.(*PanicError); ok {
+		bus.deadLetter(topic, args, DeadPanic, err, handler)
+	} else if err != nil && !errors.Is(err, ErrStopPropagation) {
+		bus.deadLetter(topic, args, DeadHandlerError, err, handler)
+	}
+}
+
+// call works like invoke and returns the results of the handler as well
+func (bus *EventBus) call(topic string, handler *eventHandler, arguments []reflect.Value, args []interface{}) (results []reflect.Value, err error) {
+	atomic.AddUint64(&handler.stats.calls, 1)
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddUint64(&handler.stats.panics, 1)
+			stack := debug.Stack()
+			bus.handlePanic(topic, handler, r, stack)
+			results, err = nil, &PanicError{Topic: topic, Recovered: r, Stack: stack}
+		}
+		if err != nil && !errors.Is(err, ErrStopPropagation) {
+			atomic.AddUint64(&handler.stats.errors, 1)
+		}
+	}()
+	middleware := bus.middlewares()
+	if len(middleware) == 0 && len(handler.middleware) == 0 {
+		if handler.typed != nil {
+			handler.typed.call(args)
+			return nil, nil
+		}
+		results = handler.callBack.Call(arguments)
+		return results, callError(results)
+	}
+	if handler.typed != nil {
+		arguments = handler.typed.values(args) // 中间件需要反射参数
+	} else {
+		arguments = append([]reflect.Value(nil), arguments...) // 中间件可能修改参数，重试时使用原始参数
 	}
-	l := len(bus.handlers[topic])
+	return bus.chain(handler, middleware)(topic, handler, arguments)
+}
 
-	if !(0 <= idx && idx < l) {
+// handleError passes the error to the error handler of the bus, if any.
+// Panics are skipped, they were already passed to the panic handler.
+func (bus *EventBus) handleError(topic string, err error) {
+	if _, ok := err.(*PanicError); ok || errors.Is(err, ErrStopPropagation) {
 		return
 	}
+	if err == ErrArgumentMismatch {
+		return // 跳过的处理器，严格模式下为 *SignatureError
+	}
+	if bus.errorHandler != nil {
+		bus.errorHandler(topic, err)
+	}
+}
 
-	copy(bus.handlers[topic][idx:], bus.handlers[topic][idx+1:])
-	bus.handlers[topic][l-1] = nil // or the zero value of T
-	bus.handlers[topic] = bus.handlers[topic][:l-1]
+// handlePanic passes the recovered panic to the panic handler of the bus
+func (bus *EventBus) handlePanic(topic string, handler *eventHandler, recovered interface{}, stack []byte) {
+	bus.recovered(topic, handler.callBack.Interface(), recovered, stack)
 }
 
-func (bus *EventBus) findHandlerIdx(topic string, callback reflect.Value) int {
-	if _, ok := bus.handlers[topic]; ok {
-		for idx, handler := range bus.handlers[topic] {
-			if handler.callBack.Type() == callback.Type() &&
-				handler.callBack.Pointer() == callback.Pointer() {
-				return idx
-			}
+// recovered passes a panic of fn, a handler or another user function, to the panic handler of the bus
+func (bus *EventBus) recovered(topic string, fn interface{}, recovered interface{}, stack []byte) {
+	if bus.panicHandler != nil {
+		bus.panicHandler(topic, fn, recovered, stack)
+	} else {
+		log.Printf("EventBus: panic in handler of %s: %v\n%s", topic, recovered, stack)
+	}
+}
+
+// callError returns the last result of a callback if it is a non-nil error
+func callError(results []reflect.Value) error {
+	if len(results) == 0 {
+		return nil
+	}
+	last := results[len(results)-1]
+	if !last.Type().Implements(errorType) {
+		return nil
+	}
+	if (last.Kind() == reflect.Interface || last.Kind() == reflect.Ptr) && last.IsNil() {
+		return nil
+	}
+	return last.Interface().(error)
+}
+
+func (bus *EventBus) findHandler(topic string, callback reflect.Value) *eventHandler {
+	for _, handler := range bus.topics().lookup(topic) {
+		if handler.callBack.Type() == callback.Type() &&
+			handler.callBack.Pointer() == callback.Pointer() {
+			return handler
 		}
 	}
-	return -1
+	return nil
 }
 
 // 处理调用的参数
+// A context.Context first parameter receives context.Background(), see PassedArgumentsContext.
 func (bus *EventBus) PassedArguments(funcType reflect.Type, args ...interface{}) ([]reflect.Value, bool) {
-	if funcType.NumIn() == 0 {
-		return make([]reflect.Value, 0), true // 无参数，直接调用
+	return bus.PassedArgumentsContext(context.Background(), funcType, args...)
+}
+
+// PassedArgumentsContext matches args with the parameters of funcType.
+// If the first parameter is a context.Context, ctx is injected into it and args are matched
+// with the remaining parameters, unless args already starts with a context.
+// A nil argument is passed as the zero value of a nilable parameter, see WithArgumentConversion for conversions.
+func (bus *EventBus) PassedArgumentsContext(ctx context.Context, funcType reflect.Type, args ...interface{}) ([]reflect.Value, bool) {
+	arguments := make([]reflect.Value, 0, funcType.NumIn()+len(args))
+	if funcType.NumIn() > 0 && funcType.In(0) == contextType && !isContextArg(args) {
+		arguments = append(arguments, reflect.ValueOf(&ctx).Elem()) // 注入上下文
+	}
+	offset := len(arguments) // 注入参数的数量
+	if funcType.NumIn() == offset {
+		return arguments, true // 无参数，直接调用
 	}
 	var variadicType reflect.Type
-	variadicIdx := funcType.NumIn() // 可变参数位置，不存在可变参数，idx为参数数量
-	if funcType.IsVariadic() {      // 具有可变参数，纠正可变参数位置
-		if funcType.NumIn()-1 > len(args) {
+	variadicIdx := funcType.NumIn() - offset // 可变参数位置，不存在可变参数，idx为参数数量
+	if funcType.IsVariadic() {               // 具有可变参数，纠正可变参数位置
+		if variadicIdx-1 > len(args) {
 			return nil, false // 缺少参数，禁止调用
 		}
 		variadicIdx -= 1
-		variadicType = funcType.In(variadicIdx).Elem()
-	} else if funcType.NumIn() != len(args) {
+		variadicType = funcType.In(offset + variadicIdx).Elem()
+	} else if variadicIdx != len(args) {
 		return nil, false // 不存在可变参数，参数数量不相等
 	}
 	// 处理参数
-	arguments := make([]reflect.Value, len(args))
 	for i, v := range args {
-		if v == nil {
-			//arguments[i] = reflect.New(funcType.In(i)).Elem()
-			arguments[i] = reflect.ValueOf(nil)
-		} else if i >= variadicIdx && !reflect.TypeOf(v).AssignableTo(variadicType) {
-			// variadic index
-			return nil, false // 可变参数不匹配
-		} else if i < variadicIdx && !reflect.TypeOf(v).AssignableTo(funcType.In(i)) {
-			// ConvertibleTo or AssignableTo 不知道其区别， 懂的可以解释一下
+		paramType := variadicType // variadic index
+		if i < variadicIdx {
+			paramType = funcType.In(offset + i)
+		}
+		value, ok := bus.argument(v, paramType)
+		if !ok {
 			return nil, false // 参数类型无法匹配
-		} else {
-			arguments[i] = reflect.ValueOf(v)
 		}
+		arguments = append(arguments, value)
 	}
 
 	return arguments, true
 }
 
+// isContextArg returns true if the first argument is a context, passed explicitly by the publisher
+func isContextArg(args []interface{}) bool {
+	if len(args) == 0 {
+		return false
+	}
+	_, ok := args[0].(context.Context)
+	return ok
+}
+
 // WaitAsync waits for all async callbacks to complete
 func (bus *EventBus) WaitAsync(wg *sync.WaitGroup) {
 	wg.Wait()
@@ -1,7 +1,10 @@
 package EventBus_test
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -203,3 +206,186 @@ func TestSubscribeAsync(t *testing.T) {
 	//	t.Fail()
 	//}
 }
+
+func TestPublishE(t *testing.T) {
+	bus := EventBus.New()
+	bus.Subscribe("topic", func(a int) error {
+		return fmt.Errorf("failed %d", a)
+	})
+	bus.Subscribe("topic", func(a int) error { return nil })
+	bus.Subscribe("topic", func(a int) {})
+
+	err := bus.PublishE("topic", 10)
+	merr, ok := err.(*EventBus.MultiError)
+	if !ok || len(merr.Errs) != 1 || merr.Errs[0].Error() != "failed 10" {
+		t.Fatal(err)
+	}
+	if bus.PublishE("topic", "no handler accepts a string") != nil {
+		t.Fail()
+	}
+}
+
+func TestMultiErrorIs(t *testing.T) {
+	panicErr := &EventBus.PanicError{Topic: "topic"}
+	merr := EventBus.NewMultiError(&[]error{fmt.Errorf("wrapped: %w", context.Canceled), panicErr})
+	// Is and As are called directly, errors.Is only looks through Unwrap() []error from Go 1.20
+	if !merr.Is(context.Canceled) || merr.Is(context.DeadlineExceeded) {
+		t.Fail()
+	}
+	var target *EventBus.PanicError
+	if !merr.As(&target) || target != panicErr {
+		t.Fail()
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	errs := make(chan error, 2)
+	bus := EventBus.New(EventBus.WithErrorHandler(func(topic string, err error) {
+		errs <- err
+	}))
+	bus.SubscribeAsync("topic", func(a int) error {
+		return fmt.Errorf("async %d", a)
+	}, false)
+	bus.Subscribe("topic", func(a int) error {
+		return fmt.Errorf("sync %d", a)
+	})
+
+	bus.WaitAsync(bus.PublishWaitAsync("topic", 1))
+	close(errs)
+	found := map[string]bool{}
+	for err := range errs {
+		found[err.Error()] = true
+	}
+	if !found["async 1"] || !found["sync 1"] {
+		t.Fatal(found)
+	}
+}
+
+func TestPanicHandler(t *testing.T) {
+	panics := make(chan interface{}, 4)
+	bus := EventBus.New(EventBus.WithPanicHandler(func(topic string, handler interface{}, recovered interface{}, stack []byte) {
+		panics <- recovered
+	}))
+	flag := 0
+	bus.Subscribe("topic", func() { panic("sync") })
+	bus.Subscribe("topic", func() { flag++ })
+	bus.SubscribeAsync("topic", func() { panic("async") }, true)
+
+	err := bus.PublishE("topic")
+	if merr, ok := err.(*EventBus.MultiError); !ok || len(merr.Errs) != 1 {
+		t.Fatal(err)
+	} else if perr, ok := merr.Errs[0].(*EventBus.PanicError); !ok || perr.Recovered != "sync" {
+		t.Fatal(merr.Errs[0])
+	}
+	// the transactional lock is released after the panic
+	bus.WaitAsync(bus.PublishWaitAsync("topic"))
+
+	if flag != 2 || len(panics) != 4 {
+		t.Fatal(flag, len(panics))
+	}
+}
+
+type ctxKey struct{}
+
+func TestPublishContext(t *testing.T) {
+	bus := EventBus.New()
+	values := []interface{}{}
+	bus.Subscribe("topic", func(ctx context.Context, a int) {
+		values = append(values, ctx.Value(ctxKey{}), a)
+	})
+	bus.Subscribe("topic", func(a int) {
+		values = append(values, a)
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace")
+	if err := bus.PublishContext(ctx, "topic", 1); err != nil {
+		t.Fatal(err)
+	}
+	// a context passed explicitly is not replaced
+	bus.Publish("topic", ctx, 2)
+	bus.Publish("topic", 3)
+	if fmt.Sprint(values) != "[trace 1 1 trace 2 <nil> 3 3]" {
+		t.Fatal(values)
+	}
+}
+
+func TestPublishContextDone(t *testing.T) {
+	bus := EventBus.New()
+	flag := 0
+	bus.Subscribe("topic", func() { flag++ })
+	bus.SubscribeAsync("topic", func() { flag++ }, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := bus.PublishContext(ctx, "topic")
+	if merr, ok := err.(*EventBus.MultiError); !ok || merr.Errs[0] != context.Canceled {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if flag != 0 {
+		t.Fatal(flag)
+	}
+}
+
+func TestSubscribeOnceConcurrent(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		bus := EventBus.New()
+		var calls int32
+		for i := 0; i < 10; i++ {
+			bus.SubscribeOnce("topic", func() { atomic.AddInt32(&calls, 1) })
+		}
+		bus.Subscribe("topic", func() {})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				bus.Publish("topic")
+			}()
+		}
+		wg.Wait()
+		if calls != 10 {
+			t.Fatal(round, calls)
+		}
+		if !bus.HasCallback("topic") {
+			t.Fatal("the other handler was removed")
+		}
+	}
+}
+
+func TestPublishConcurrentSubscribe(t *testing.T) {
+	bus := EventBus.New()
+	var calls int32
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				sub, _ := bus.SubscribeHandle("order:*", func(a int) { atomic.AddInt32(&calls, 1) })
+				bus.SubscribeOnceAsync("order:created", func(a int) {})
+				sub.Unsubscribe()
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				bus.WaitAsync(bus.PublishWaitAsync("order:created", j))
+				bus.HasCallback("order:created")
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,171 @@
+package EventBus_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestPublishEvent(t *testing.T) {
+	bus := EventBus.New(EventBus.WithSource("orders-service"))
+	var got *EventBus.Event
+	values := []int{}
+	bus.Subscribe("orders:created", func(e *EventBus.Event, i int) { got = e })
+	bus.Subscribe("orders:created", func(i int) { values = append(values, i) })
+
+	err := bus.PublishEvent(context.Background(), &EventBus.Event{
+		Topic:   "orders:created",
+		Headers: map[string]string{"trace-id": "abc"},
+		Payload: []interface{}{1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.ID == "" || got.Timestamp.IsZero() || got.Source != "orders-service" {
+		t.Fatal(got)
+	}
+	if got.Topic != "orders:created" || got.Headers["trace-id"] != "abc" || fmt.Sprint(got.Payload) != "[1]" {
+		t.Fatal(got)
+	}
+	if fmt.Sprint(values) != "[1]" {
+		t.Fatal(values)
+	}
+
+	// Publish creates the envelope
+	id := got.ID
+	bus.Publish("orders:created", 2)
+	if got.ID == "" || got.ID == id || got.Headers != nil || fmt.Sprint(got.Payload) != "[2]" {
+		t.Fatal(got)
+	}
+}
+
+func TestPublishEventContext(t *testing.T) {
+	type key struct{}
+	bus := EventBus.New()
+	var got *EventBus.Event
+	var value interface{}
+	bus.Subscribe("topic", func(ctx context.Context, e *EventBus.Event, s string) {
+		got, value = e, ctx.Value(key{})
+		if s != "a" {
+			t.Fail()
+		}
+	})
+	ctx := context.WithValue(context.Background(), key{}, 1)
+	bus.PublishEvent(ctx, &EventBus.Event{ID: "event-1", Topic: "topic", Payload: []interface{}{"a"}})
+	if got == nil || got.ID != "event-1" || value != 1 {
+		t.Fatal(got, value)
+	}
+
+	// a context passed by the publisher precedes the envelope
+	bus.PublishContext(ctx, "topic", "a")
+	if got.ID == "event-1" || value != 1 {
+		t.Fatal(got, value)
+	}
+}
+
+func TestEventReplay(t *testing.T) {
+	bus := EventBus.New(EventBus.WithHistory("metrics", 10, 0))
+	var published *EventBus.Event
+	bus.Subscribe("metrics", func(e *EventBus.Event, i int) { published = e })
+	bus.Publish("metrics", 1)
+	bus.PublishRetained("state", 2)
+
+	var replayed *EventBus.Event
+	bus.SubscribeFrom("metrics", func(e *EventBus.Event, i int) { replayed = e }, time.Time{})
+	if published == nil || replayed == nil || replayed.ID != published.ID || !replayed.Timestamp.Equal(published.Timestamp) {
+		t.Fatal(published, replayed)
+	}
+
+	var retained *EventBus.Event
+	bus.SubscribeWithOptions("state", func(e *EventBus.Event, i int) { retained = e }, EventBus.WithReplayRetained())
+	if retained == nil || retained.ID == "" || retained.Topic != "state" || fmt.Sprint(retained.Payload) != "[2]" {
+		t.Fatal(retained)
+	}
+}
+
+func TestEventHeadersCopy(t *testing.T) {
+	bus := EventBus.New(EventBus.WithHistory("topic", 10, 0))
+	seen := make(chan string, 1)
+	bus.Subscribe("topic", func(e *EventBus.Event, i int) { e.Headers["user"] = "enriched" })
+	bus.SubscribeAsync("topic", func(e *EventBus.Event, i int) { seen <- e.Headers["user"] }, false)
+	bus.PublishEvent(context.Background(), &EventBus.Event{
+		Topic:   "topic",
+		Headers: map[string]string{"user": "original"},
+		Payload: []interface{}{1},
+	})
+	// every handler receives its own copy of the headers, the replays as well
+	if user := <-seen; user != "original" {
+		t.Fatal(user)
+	}
+	var replayed *EventBus.Event
+	bus.SubscribeFrom("topic", func(e *EventBus.Event, i int) { replayed = e }, time.Time{})
+	if replayed == nil || replayed.Headers["user"] != "original" {
+		t.Fatal(replayed)
+	}
+}
+
+func TestEventRequest(t *testing.T) {
+	bus := EventBus.New(EventBus.WithSource("test"))
+	bus.Respond("double", func(ctx context.Context, e *EventBus.Event, i int) (int, string) {
+		return i * 2, e.Source
+	})
+	results, err := bus.Request(context.Background(), "double", 2)
+	if err != nil || fmt.Sprint(results) != "[4 test]" {
+		t.Fatal(results, err)
+	}
+}
+
+func TestEventSignature(t *testing.T) {
+	bus := EventBus.New(EventBus.WithStrictArguments()).(*EventBus.EventBus)
+	if err := bus.DeclareTopic("topic", reflect.TypeOf(0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe("topic", func(e *EventBus.Event, i int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe("topic", func(e *EventBus.Event, s string) {}); err == nil {
+		t.Fatal("expected a declaration mismatch")
+	}
+	if err := bus.PublishE("topic", 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPushEventEnvelope(t *testing.T) {
+	clientBus := EventBus.NewClient("localhost:2016", "/_client_bus_", EventBus.New())
+	var got *EventBus.Event
+	clientBus.EventBus().Subscribe("topic", func(e *EventBus.Event, a int) {
+		if a != 10 {
+			t.Fail()
+		}
+		got = e
+	})
+
+	reply := new(bool)
+	clientArg := &EventBus.ClientArg{
+		Args:  []interface{}{10},
+		Topic: "topic",
+		Event: &EventBus.Event{ID: "remote-1", Source: "server", Headers: map[string]string{"k": "v"}},
+	}
+	clientBus.Service().PushEvent(clientArg, reply)
+	if !*reply || got == nil || got.ID != "remote-1" || got.Source != "server" || got.Headers["k"] != "v" {
+		t.Fatal(got)
+	}
+
+	// the client subscribed to a pattern receives the topic the event was published to
+	var wildcard *EventBus.Event
+	clientBus.EventBus().Subscribe("order:*", func(e *EventBus.Event, a int) { wildcard = e })
+	clientArg = &EventBus.ClientArg{
+		Args:  []interface{}{10},
+		Topic: "order:*",
+		Event: &EventBus.Event{ID: "remote-2", Topic: "order:created"},
+	}
+	clientBus.Service().PushEvent(clientArg, reply)
+	if wildcard == nil || wildcard.ID != "remote-2" || wildcard.Topic != "order:created" {
+		t.Fatal(wildcard)
+	}
+}
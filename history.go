@@ -0,0 +1,140 @@
+package EventBus
+
+import (
+	"reflect"
+	"time"
+)
+
+// historyConfig is the history kept for the topics matching pattern
+type historyConfig struct {
+	pattern string
+	size    int           // max number of events per topic, 0: no limit
+	maxAge  time.Duration // max age of the events, 0: no limit
+}
+
+// WithHistory keeps the last size events, no older than maxAge, of every topic matching the pattern.
+// A size or maxAge of 0 means no limit, the option is ignored if both are 0.
+// The history is replayed to the handlers subscribed with SubscribeFrom.
+func WithHistory(pattern string, size int, maxAge time.Duration) BusOption {
+	return func(bus *EventBus) {
+		if size < 0 {
+			size = 0
+		}
+		if size == 0 && maxAge <= 0 {
+			return // 无界的历史
+		}
+		bus.historyCfg = append(bus.historyCfg, historyConfig{pattern: pattern, size: size, maxAge: maxAge})
+	}
+}
+
+// SubscribeFrom subscribes to a topic and replays the history of the topics matching it,
+// from the events published at or after since, before any event published afterwards.
+// A zero since replays the whole history. The options are those of SubscribeWithOptions.
+func (bus *EventBus) SubscribeFrom(topic string, fn interface{}, since time.Time, opts ...SubscribeOption) (Subscription, error) {
+	handler := &eventHandler{callBack: reflect.ValueOf(fn), history: true, since: since}
+	for _, opt := range opts {
+		opt(handler)
+	}
+	return bus.doSubscribe(topic, fn, handler)
+}
+
+// historyOf returns the history config of the topic, nil if the topic keeps no history
+func (bus *EventBus) historyOf(topic string) *historyConfig {
+	for i := range bus.historyCfg {
+		if TopicMatch(bus.historyCfg[i].pattern, topic) {
+			return &bus.historyCfg[i]
+		}
+	}
+	return nil
+}
+
+// record appends the event to the history of its topic, it is called with retainLock held
+func (bus *EventBus) record(event *storedEvent, cfg *historyConfig) {
+	if bus.history == nil {
+		bus.history = make(map[string]*eventRing)
+	}
+	ring, ok := bus.history[event.topic]
+	if !ok {
+		ring = &eventRing{size: cfg.size, maxAge: cfg.maxAge}
+		bus.history[event.topic] = ring
+	}
+	ring.expire(event.event.Timestamp)
+	ring.push(event)
+}
+
+// historySince returns the events of the topics matching the pattern published at or after since,
+// it is called with retainLock held
+func (bus *EventBus) historySince(pattern string, since time.Time) []*storedEvent {
+	now := time.Now()
+	var events []*storedEvent
+	for topic, ring := range bus.history {
+		if ring.expire(now); ring.count == 0 {
+			delete(bus.history, topic) // 全部过期
+			continue
+		}
+		if !TopicMatch(pattern, topic) {
+			continue
+		}
+		for i := 0; i < ring.count; i++ {
+			if event := ring.at(i); !event.event.Timestamp.Before(since) {
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// eventRing is a ring buffer of the events of a topic, oldest first.
+// It grows up to size, or without limit if size is 0.
+type eventRing struct {
+	buf    []*storedEvent
+	head   int // index of the oldest event
+	count  int
+	size   int
+	maxAge time.Duration
+}
+
+func (r *eventRing) at(i int) *storedEvent {
+	return r.buf[(r.head+i)%len(r.buf)]
+}
+
+// push appends the event, overwriting the oldest event if the ring is full
+func (r *eventRing) push(event *storedEvent) {
+	if r.count == len(r.buf) {
+		if r.size > 0 && r.count == r.size {
+			r.buf[r.head] = event
+			r.head = (r.head + 1) % len(r.buf)
+			return
+		}
+		r.grow()
+	}
+	r.buf[(r.head+r.count)%len(r.buf)] = event
+	r.count++
+}
+
+func (r *eventRing) grow() {
+	n := 2 * len(r.buf)
+	if n == 0 {
+		n = 8
+	}
+	if r.size > 0 && n > r.size {
+		n = r.size
+	}
+	buf := make([]*storedEvent, n)
+	for i := 0; i < r.count; i++ {
+		buf[i] = r.at(i)
+	}
+	r.buf, r.head = buf, 0
+}
+
+// expire removes the events older than maxAge at now
+func (r *eventRing) expire(now time.Time) {
+	if r.maxAge <= 0 {
+		return
+	}
+	for r.count > 0 && now.Sub(r.at(0).event.Timestamp) > r.maxAge {
+		r.buf[r.head] = nil
+		r.head = (r.head + 1) % len(r.buf)
+		r.count--
+	}
+}
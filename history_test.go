@@ -0,0 +1,92 @@
+package EventBus_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestSubscribeFrom(t *testing.T) {
+	bus := EventBus.New(EventBus.WithHistory("orders:*", 3, 0))
+	for i := 0; i < 5; i++ {
+		bus.Publish("orders:created", i)
+	}
+	bus.Publish("orders:paid", 10)
+	bus.Publish("users:created", 20) // no history
+
+	values := []int{}
+	bus.SubscribeFrom("orders:#", func(i int) { values = append(values, i) }, time.Time{})
+	if fmt.Sprint(values) != "[2 3 4 10]" {
+		t.Fatal(values)
+	}
+	bus.Publish("orders:created", 5)
+	if fmt.Sprint(values) != "[2 3 4 10 5]" {
+		t.Fatal(values)
+	}
+
+	since := time.Now()
+	bus.Publish("orders:created", 6)
+	values = values[:0]
+	bus.SubscribeFrom("orders:created", func(i int) { values = append(values, i) }, since)
+	if fmt.Sprint(values) != "[6]" {
+		t.Fatal(values)
+	}
+}
+
+func TestSubscribeFromMaxAge(t *testing.T) {
+	bus := EventBus.New(EventBus.WithHistory("#", 0, 50*time.Millisecond))
+	bus.Publish("metrics", 1)
+	time.Sleep(100 * time.Millisecond)
+	bus.Publish("metrics", 2)
+
+	values := []int{}
+	bus.SubscribeFrom("metrics", func(i int) { values = append(values, i) }, time.Time{})
+	if fmt.Sprint(values) != "[2]" {
+		t.Fatal(values)
+	}
+}
+
+func TestSubscribeFromRetained(t *testing.T) {
+	bus := EventBus.New(EventBus.WithHistory("state", 10, 0))
+	bus.PublishRetained("state", 1)
+	bus.Publish("state", 2)
+
+	values := []int{}
+	// the retained event is in the history as well, it is replayed once
+	bus.SubscribeFrom("state", func(i int) { values = append(values, i) }, time.Time{}, EventBus.WithReplayRetained())
+	if fmt.Sprint(values) != "[1 2]" {
+		t.Fatal(values)
+	}
+}
+
+func TestSubscribeFromConcurrent(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		bus := EventBus.New(EventBus.WithHistory("ticks", 1000, 0))
+		var wg sync.WaitGroup
+		var last int64 = -1
+		var calls, gaps int64
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				bus.Publish("ticks", i)
+			}
+		}()
+		bus.SubscribeFrom("ticks", func(i int) {
+			atomic.AddInt64(&calls, 1)
+			if int64(i) != atomic.LoadInt64(&last)+1 {
+				atomic.AddInt64(&gaps, 1)
+			}
+			atomic.StoreInt64(&last, int64(i))
+		}, time.Time{})
+		wg.Wait()
+		// every event exactly once, in order, across replay and live delivery
+		if gaps != 0 || calls != 200 {
+			t.Fatal(round, gaps, calls)
+		}
+	}
+}
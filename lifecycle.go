@@ -0,0 +1,184 @@
+package EventBus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBusClosed is returned by publish and subscribe calls of a closed bus
+var ErrBusClosed = errors.New("event bus is closed")
+
+// PendingHandler is an async handler which did not finish in time
+type PendingHandler struct {
+	Topic        string // published topic
+	Subscription Subscription
+}
+
+// DrainError is returned by Close and Drain when ctx is done before all async handlers finished
+type DrainError struct {
+	Err     error // ctx.Err()
+	Pending []PendingHandler
+}
+
+func (e *DrainError) Error() string {
+	topics := make([]string, 0, len(e.Pending))
+	for _, p := range e.Pending {
+		topics = append(topics, p.Topic)
+	}
+	return fmt.Sprintf("%d async handlers did not finish (%s): %v", len(e.Pending), strings.Join(topics, ","), e.Err)
+}
+
+func (e *DrainError) Unwrap() error {
+	return e.Err
+}
+
+// inflightCall is an async callback which is queued or running
+type inflightCall struct {
+	topic   string
+	handler *eventHandler
+}
+
+// tracker keeps the async callbacks of all publishes
+type tracker struct {
+	lock  sync.Mutex
+	calls map[*inflightCall]struct{}
+	idle  chan struct{} // closed once calls is empty
+}
+
+func (t *tracker) add(topic string, handler *eventHandler) *inflightCall {
+	call := &inflightCall{topic: topic, handler: handler}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.calls == nil {
+		t.calls = make(map[*inflightCall]struct{})
+	}
+	t.calls[call] = struct{}{}
+	return call
+}
+
+func (t *tracker) done(call *inflightCall) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.calls, call)
+	if len(t.calls) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// wait waits until no callback is in flight, returns the pending callbacks if ctx is done before
+func (t *tracker) wait(ctx context.Context) []PendingHandler {
+	t.lock.Lock()
+	if len(t.calls) == 0 {
+		t.lock.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.lock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	pending := make([]PendingHandler, 0, len(t.calls))
+	for call := range t.calls {
+		pending = append(pending, PendingHandler{Topic: call.topic, Subscription: call.handler})
+	}
+	return pending
+}
+
+// inflight counts the publishes in progress, Close waits for them before draining
+type inflight struct {
+	lock  sync.Mutex
+	count int
+	idle  chan struct{} // closed once count is 0
+}
+
+func (p *inflight) enter() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.count++
+}
+
+func (p *inflight) leave() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.count--
+	if p.count == 0 && p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+}
+
+// wait waits until no publish is in progress, returns false if ctx is done before
+func (p *inflight) wait(ctx context.Context) bool {
+	p.lock.Lock()
+	if p.count == 0 {
+		p.lock.Unlock()
+		return true
+	}
+	if p.idle == nil {
+		p.idle = make(chan struct{})
+	}
+	idle := p.idle
+	p.lock.Unlock()
+	select {
+	case <-idle:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Closed returns true once Close is called
+func (bus *EventBus) Closed() bool {
+	return atomic.LoadInt32(&bus.closed) == 1
+}
+
+// Drain waits until all async callbacks, of all publishes, have finished.
+// Returns a *DrainError with the unfinished handlers if ctx is done before.
+func (bus *EventBus) Drain(ctx context.Context) error {
+	if pending := bus.running.wait(ctx); len(pending) > 0 {
+		return &DrainError{Err: ctx.Err(), Pending: pending}
+	}
+	return nil
+}
+
+// Close refuses new publishes and subscriptions, cancels the scheduled events, waits for the publishes
+// in progress, flushes the batches of SubscribeInBatches, then waits like Drain for the async callbacks.
+// The workers of the pool are stopped once the async callbacks have finished.
+// Close must not be called by a sync handler without a deadline, it would wait for its own publish.
+func (bus *EventBus) Close(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&bus.closed, 0, 1) {
+		return ErrBusClosed
+	}
+	close(bus.quit)
+	bus.scheduler.stop()
+	bus.publishing.wait(ctx) // 超时由 Drain 报告
+	bus.batchers.Range(func(_, b interface{}) bool {
+		b.(*batcher).flush()
+		return true
+	})
+	err := bus.Drain(ctx)
+	if bus.pool != nil {
+		if err == nil {
+			bus.pool.stop()
+		} else {
+			go func() { // 等待剩余的任务完成
+				bus.running.wait(context.Background())
+				bus.pool.stop()
+			}()
+		}
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package EventBus_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestClose(t *testing.T) {
+	bus := EventBus.New(EventBus.WithWorkerPool(2, 8, EventBus.OverflowBlock))
+	var done int32
+	bus.SubscribeAsync("topic", func() {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&done, 1)
+	}, false)
+	for i := 0; i < 4; i++ {
+		bus.Publish("topic")
+	}
+
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&done) != 4 {
+		t.Fatal(done)
+	}
+	if bus.PublishE("topic") == nil || bus.Subscribe("topic", func() {}) != EventBus.ErrBusClosed {
+		t.Fail()
+	}
+	if bus.Close(context.Background()) != EventBus.ErrBusClosed {
+		t.Fail()
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	bus := EventBus.New()
+	block := make(chan struct{})
+	defer close(block)
+	sub, _ := bus.SubscribeAsyncHandle("topic:slow", func() { <-block }, false)
+	bus.Subscribe("topic:fast", func() {})
+	bus.Publish("topic:slow")
+	bus.Publish("topic:fast")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := bus.Close(ctx)
+	var derr *EventBus.DrainError
+	if !errors.As(err, &derr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	if len(derr.Pending) != 1 || derr.Pending[0].Topic != "topic:slow" || derr.Pending[0].Subscription != sub {
+		t.Fatal(derr.Pending)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	bus := EventBus.New()
+	var done int32
+	bus.SubscribeAsync("topic", func() {
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&done, 1)
+	}, true)
+	bus.Publish("topic")
+	bus.Publish("topic")
+	if err := bus.Drain(context.Background()); err != nil || atomic.LoadInt32(&done) != 2 {
+		t.Fatal(err, done)
+	}
+	// the bus is still open
+	bus.Publish("topic")
+	bus.Drain(context.Background())
+	if atomic.LoadInt32(&done) != 3 {
+		t.Fatal(done)
+	}
+}
+
+func TestCloseConcurrentPublish(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		bus := EventBus.New(EventBus.WithWorkerPool(1, 1, EventBus.OverflowBlock)).(*EventBus.EventBus)
+		var calls int32
+		bus.SubscribeWithOptions("topic", func() { time.Sleep(time.Millisecond) }, EventBus.WithPriority(10))
+		bus.SubscribeAsync("topic", func() {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(time.Millisecond)
+		}, false)
+		done := make(chan struct{})
+		for p := 0; p < 4; p++ {
+			go func() {
+				for bus.PublishE("topic") == nil {
+				}
+				done <- struct{}{}
+			}()
+		}
+		time.Sleep(2 * time.Millisecond)
+
+		// the publishes in flight during Close are waited for, with their async callbacks
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := bus.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+		closed := atomic.LoadInt32(&calls)
+		for p := 0; p < 4; p++ {
+			select {
+			case <-done:
+			case <-ctx.Done():
+				t.Fatal("publisher blocked")
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if n := atomic.LoadInt32(&calls); n != closed {
+			t.Fatal("async callbacks called after Close", closed, n)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,166 @@
+package EventBus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter decides when the events of a handler are delivered
+type limiter interface {
+	// admit returns true if the event is delivered now, otherwise the limiter calls deliver later or drops it
+	admit(topic string, deliver func()) bool
+}
+
+// WithDebounce delivers only the last event of a burst, once no event was published for the delay.
+// The delivery is made from a timer goroutine, Drain and Close wait for it.
+// A delayed delivery receives the values of the publisher's ctx but not its cancellation or deadline,
+// the event is delivered even if the publisher cancelled ctx after the publish.
+// WithDebounce, WithThrottle and WithRateLimit replace each other.
+func WithDebounce(delay time.Duration) SubscribeOption {
+	return func(handler *eventHandler) {
+		handler.limiter = &debouncer{handler: handler, delay: delay}
+	}
+}
+
+// WithThrottle delivers at most one event per interval. With leading the first event of an interval is
+// delivered immediately, with trailing the last event of an interval is delivered at its end,
+// the other events are dropped. The first event is delivered immediately if both are false.
+// The trailing event is delivered like the event of WithDebounce.
+func WithThrottle(interval time.Duration, leading, trailing bool) SubscribeOption {
+	if !leading && !trailing {
+		leading = true
+	}
+	return func(handler *eventHandler) {
+		handler.limiter = &throttler{handler: handler, interval: interval, leading: leading, trailing: trailing}
+	}
+}
+
+// WithRateLimit delivers up to rps events per second on average, and bursts of up to burst events,
+// the events beyond are dropped.
+func WithRateLimit(rps float64, burst int) SubscribeOption {
+	if burst < 1 {
+		burst = 1
+	}
+	return func(handler *eventHandler) {
+		handler.limiter = &rateLimiter{rate: rps, burst: float64(burst), tokens: float64(burst)}
+	}
+}
+
+// detached is a context with the values of its parent, but never done
+type detached struct {
+	context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detached{ctx}
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
+
+// debouncer delays the delivery until the events stop
+type debouncer struct {
+	lock    sync.Mutex
+	handler *eventHandler
+	delay   time.Duration
+	timer   *time.Timer
+	pending func()
+	call    *inflightCall // keeps Drain waiting for the pending delivery
+}
+
+func (d *debouncer) admit(topic string, deliver func()) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.pending = deliver
+	if d.timer == nil {
+		d.call = d.handler.bus.running.add(topic, d.handler)
+		d.timer = time.AfterFunc(d.delay, d.fire)
+	} else if d.timer.Stop() {
+		d.timer.Reset(d.delay)
+	} // 计时器已触发，fire 将交付最新的事件
+	return false
+}
+
+func (d *debouncer) fire() {
+	d.lock.Lock()
+	deliver, call := d.pending, d.call
+	d.pending, d.call, d.timer = nil, nil, nil
+	d.lock.Unlock()
+	deliver()
+	d.handler.bus.running.done(call)
+}
+
+// throttler delivers one event per interval
+type throttler struct {
+	lock     sync.Mutex
+	handler  *eventHandler
+	interval time.Duration
+	leading  bool
+	trailing bool
+	open     bool   // an interval is running
+	pending  func() // trailing event of the interval
+	call     *inflightCall
+}
+
+func (t *throttler) admit(topic string, deliver func()) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.open {
+		t.open = true
+		t.call = t.handler.bus.running.add(topic, t.handler)
+		time.AfterFunc(t.interval, t.fire)
+		if t.leading {
+			return true
+		}
+	}
+	if t.trailing {
+		t.pending = deliver
+	}
+	return false
+}
+
+// fire ends the interval, the trailing event starts the next interval
+func (t *throttler) fire() {
+	t.lock.Lock()
+	deliver := t.pending
+	t.pending = nil
+	if deliver != nil {
+		time.AfterFunc(t.interval, t.fire)
+		t.lock.Unlock()
+		deliver()
+		return
+	}
+	call := t.call
+	t.open, t.call = false, nil
+	t.lock.Unlock()
+	t.handler.bus.running.done(call)
+}
+
+// rateLimiter is a token bucket
+type rateLimiter struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (r *rateLimiter) admit(topic string, deliver func()) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	if !r.last.IsZero() {
+		r.tokens += now.Sub(r.last).Seconds() * r.rate
+		if r.tokens > r.burst {
+			r.tokens = r.burst
+		}
+	}
+	r.last = now
+	if r.tokens < 1 {
+		return false // 丢弃
+	}
+	r.tokens--
+	return true
+}
@@ -0,0 +1,116 @@
+package EventBus_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+// recorder collects the values received by a handler
+type recorder struct {
+	lock   sync.Mutex
+	values []int
+}
+
+func (r *recorder) add(v int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.values = append(r.values, v)
+}
+
+func (r *recorder) String() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return fmt.Sprint(r.values)
+}
+
+func TestDebounce(t *testing.T) {
+	bus := EventBus.New()
+	rec := &recorder{}
+	bus.SubscribeWithOptions("topic", rec.add, EventBus.WithAsync(false), EventBus.WithDebounce(30*time.Millisecond))
+	for i := 1; i <= 5; i++ {
+		bus.Publish("topic", i)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if rec.String() != "[]" {
+		t.Fatal(rec)
+	}
+	// Drain waits for the pending delivery
+	if err := bus.Drain(context.Background()); err != nil || rec.String() != "[5]" {
+		t.Fatal(err, rec)
+	}
+	bus.Publish("topic", 6)
+	bus.Drain(context.Background())
+	if rec.String() != "[5 6]" {
+		t.Fatal(rec)
+	}
+}
+
+func TestDebounceCancelledContext(t *testing.T) {
+	type key struct{}
+	bus := EventBus.New()
+	values := make(chan interface{}, 1)
+	bus.SubscribeWithOptions("topic", func(ctx context.Context, i int) {
+		if ctx.Err() == nil {
+			values <- ctx.Value(key{})
+		}
+	}, EventBus.WithAsync(false), EventBus.WithDebounce(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	bus.PublishContext(ctx, "topic", 1)
+	cancel()
+	// the delayed delivery keeps the values of ctx but not its cancellation
+	bus.Drain(context.Background())
+	select {
+	case v := <-values:
+		if v != "value" {
+			t.Fatal(v)
+		}
+	default:
+		t.Fatal("event not delivered")
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	for _, c := range []struct {
+		leading, trailing bool
+		expected          string
+	}{
+		{true, false, "[1]"},
+		{false, true, "[3]"},
+		{true, true, "[1 3]"},
+	} {
+		bus := EventBus.New()
+		rec := &recorder{}
+		bus.SubscribeWithOptions("topic", rec.add, EventBus.WithThrottle(50*time.Millisecond, c.leading, c.trailing))
+		bus.Publish("topic", 1)
+		bus.Publish("topic", 2)
+		bus.Publish("topic", 3)
+		bus.Drain(context.Background())
+		if rec.String() != c.expected {
+			t.Fatal(c, rec)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	bus := EventBus.New()
+	rec := &recorder{}
+	bus.SubscribeWithOptions("topic", rec.add, EventBus.WithRateLimit(20, 2))
+	for i := 1; i <= 5; i++ {
+		bus.Publish("topic", i)
+	}
+	if rec.String() != "[1 2]" {
+		t.Fatal(rec)
+	}
+	time.Sleep(60 * time.Millisecond) // one token refilled
+	bus.Publish("topic", 6)
+	bus.Publish("topic", 7)
+	if rec.String() != "[1 2 6]" {
+		t.Fatal(rec)
+	}
+}
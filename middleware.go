@@ -0,0 +1,92 @@
+package EventBus
+
+import (
+	"context"
+	"reflect"
+)
+
+// Invoker calls a handler with the arguments matched by PassedArguments,
+// and returns the results and the error returned by the handler.
+type Invoker func(topic string, handler Subscription, args []reflect.Value) ([]reflect.Value, error)
+
+// Middleware wraps the invocation of handlers, e.g. for logging, timing or tracing.
+// It may change the arguments, or return an error without calling next.
+type Middleware func(next Invoker) Invoker
+
+// Use adds middleware around the invocation of every handler of the bus.
+// The first middleware is the outermost, the middleware of a subscription runs inside.
+func (bus *EventBus) Use(middleware ...Middleware) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+	current := bus.middlewares()
+	next := make([]Middleware, 0, len(current)+len(middleware))
+	next = append(next, current...)
+	bus.middleware.Store(append(next, middleware...))
+}
+
+// WithMiddleware adds middleware around the invocation of the handler
+func WithMiddleware(middleware ...Middleware) SubscribeOption {
+	return func(handler *eventHandler) {
+		handler.middleware = append(handler.middleware, middleware...)
+	}
+}
+
+func (bus *EventBus) middlewares() []Middleware {
+	middleware, _ := bus.middleware.Load().([]Middleware)
+	return middleware
+}
+
+// chain wraps the call of the handler with the middleware of the handler, then of the bus
+func (bus *EventBus) chain(handler *eventHandler, middleware []Middleware) Invoker {
+	var invoker Invoker = func(topic string, _ Subscription, args []reflect.Value) ([]reflect.Value, error) {
+		results := handler.callBack.Call(args)
+		return results, callError(results)
+	}
+	for i := len(handler.middleware) - 1; i >= 0; i-- {
+		invoker = handler.middleware[i](invoker)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		invoker = middleware[i](invoker)
+	}
+	return invoker
+}
+
+// Publication is an event about to be dispatched, publish hooks may change it
+type Publication struct {
+	Ctx   context.Context
+	Topic string
+	Args  []interface{}
+}
+
+// PublishHook is called before an event is dispatched. It may inspect and change the publication,
+// e.g. enrich the arguments or rewrite the topic, returning an error rejects the event.
+type PublishHook func(pub *Publication) error
+
+// OnPublish adds hooks called in order before every event is dispatched.
+// The error of a rejected event is returned by PublishE, or passed to the error handler by Publish.
+func (bus *EventBus) OnPublish(hooks ...PublishHook) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+	current := bus.publishHooks()
+	next := make([]PublishHook, 0, len(current)+len(hooks))
+	next = append(next, current...)
+	bus.hooks.Store(append(next, hooks...))
+}
+
+func (bus *EventBus) publishHooks() []PublishHook {
+	hooks, _ := bus.hooks.Load().([]PublishHook)
+	return hooks
+}
+
+// beforePublish runs the publish hooks, returns the publication to dispatch
+func (bus *EventBus) beforePublish(pub *Publication) (*Publication, error) {
+	for _, hook := range bus.publishHooks() {
+		if err := hook(pub); err != nil {
+			return nil, err
+		}
+	}
+	if pub.Ctx == nil {
+		pub.Ctx = context.Background()
+	}
+	return pub, nil
+}
@@ -0,0 +1,85 @@
+package EventBus_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestMiddleware(t *testing.T) {
+	bus := EventBus.New()
+	calls := []string{}
+	trace := func(name string) EventBus.Middleware {
+		return func(next EventBus.Invoker) EventBus.Invoker {
+			return func(topic string, handler EventBus.Subscription, args []reflect.Value) ([]reflect.Value, error) {
+				calls = append(calls, name+">"+topic)
+				return next(topic, handler, args)
+			}
+		}
+	}
+	bus.(*EventBus.EventBus).Use(trace("outer"), trace("inner"))
+	bus.SubscribeWithOptions("order:*", func(a int) { calls = append(calls, "handler") }, EventBus.WithMiddleware(trace("sub")))
+	EventBus.Subscribe(bus, "order:created", func(a int) { calls = append(calls, "typed") })
+
+	bus.Publish("order:created", 1)
+	expected := "[outer>order:created inner>order:created sub>order:created handler outer>order:created inner>order:created typed]"
+	if got := fmt.Sprint(calls); got != expected {
+		t.Fatal(got)
+	}
+}
+
+func TestMiddlewareReject(t *testing.T) {
+	bus := EventBus.New()
+	denied := errors.New("denied")
+	flag := 0
+	bus.(*EventBus.EventBus).Use(func(next EventBus.Invoker) EventBus.Invoker {
+		return func(topic string, handler EventBus.Subscription, args []reflect.Value) ([]reflect.Value, error) {
+			if args[0].Int() < 0 {
+				return nil, denied
+			}
+			args[0] = reflect.ValueOf(int(args[0].Int() * 2))
+			return next(topic, handler, args)
+		}
+	})
+	bus.Subscribe("topic", func(a int) { flag += a })
+
+	if err := bus.PublishE("topic", -1); err == nil || err.(*EventBus.MultiError).Errs[0] != denied {
+		t.Fatal(err)
+	}
+	bus.Publish("topic", 2)
+	if flag != 4 {
+		t.Fatal(flag)
+	}
+}
+
+func TestPublishHook(t *testing.T) {
+	bus := EventBus.New()
+	errMissingTenant := errors.New("missing tenant")
+	bus.(*EventBus.EventBus).OnPublish(func(pub *EventBus.Publication) error {
+		if len(pub.Args) == 0 {
+			return errMissingTenant
+		}
+		pub.Topic = pub.Args[0].(string) + ":" + pub.Topic // 按租户路由
+		return nil
+	}, func(pub *EventBus.Publication) error {
+		pub.Args = append(pub.Args, "enriched")
+		return nil
+	})
+	calls := []string{}
+	bus.Subscribe("acme:order:created", func(tenant, extra string) { calls = append(calls, tenant, extra) })
+	bus.Subscribe("order:created", func() { calls = append(calls, "unscoped") })
+
+	if err := bus.PublishE("order:created", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	err := bus.PublishE("order:created")
+	if !errors.Is(err, errMissingTenant) {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(calls) != "[acme enriched]" {
+		t.Fatal(calls)
+	}
+}
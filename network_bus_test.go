@@ -49,7 +49,7 @@ func TestPushEvent(t *testing.T) {
 	eventArgs := make([]interface{}, 1)
 	eventArgs[0] = 10
 
-	clientArg := &EventBus.ClientArg{eventArgs, "topic"}
+	clientArg := &EventBus.ClientArg{Args: eventArgs, Topic: "topic"}
 	reply := new(bool)
 
 	fn := func(a int) {
@@ -0,0 +1,28 @@
+package EventBus
+
+// BusOption configures an EventBus created by New
+type BusOption func(*EventBus)
+
+// ErrorHandler receives errors returned by handlers that are not returned to the publisher,
+// e.g. errors of async handlers or of sync handlers invoked by Publish.
+type ErrorHandler func(topic string, err error)
+
+// WithErrorHandler sets the error sink of the bus
+func WithErrorHandler(handler ErrorHandler) BusOption {
+	return func(bus *EventBus) {
+		bus.errorHandler = handler
+	}
+}
+
+// PanicHandler receives the value recovered from a panicking handler and the stack of the panic.
+// handler is the callback subscribed to the topic, or the user function which panicked, e.g. the
+// arguments factory of a scheduled event.
+type PanicHandler func(topic string, handler interface{}, recovered interface{}, stack []byte)
+
+// WithPanicHandler sets the hook called when a handler panics,
+// by default the panic is written to the standard logger.
+func WithPanicHandler(handler PanicHandler) BusOption {
+	return func(bus *EventBus) {
+		bus.panicHandler = handler
+	}
+}
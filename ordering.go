@@ -0,0 +1,96 @@
+package EventBus
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// WithOrderingKey runs the handler asynchronously and delivers the events with the same key in the order
+// they are published, while the events with different keys run in parallel on the worker pool.
+// key receives the arguments of the event, without a context passed by the publisher, e.g. it returns
+// the order ID so that the events of an order are processed one after the other.
+//
+// The overflow policy of the pool applies to the first pending event of a key, the following events wait
+// in the queue of their key. They are run by the pool when it has a free slot, otherwise in their own
+// goroutine, a worker never waits for its own pool. A panic of key is passed to the panic handler and
+// the event is dropped.
+func WithOrderingKey(key func(args []interface{}) string) SubscribeOption {
+	return func(handler *eventHandler) {
+		handler.async = true
+		handler.ordering = &orderedQueues{key: key, queues: make(map[string][]asyncTask)}
+	}
+}
+
+// orderedQueues holds the pending tasks of a handler per ordering key,
+// the first task of a queue is running or submitted to the pool.
+type orderedQueues struct {
+	lock   sync.Mutex
+	key    func(args []interface{}) string
+	queues map[string][]asyncTask
+}
+
+// submit queues the task behind the tasks of its key, it is submitted once they are done
+func (q *orderedQueues) submit(bus *EventBus, topic string, args []interface{}, task asyncTask) error {
+	if isContextArg(args) {
+		args = args[1:]
+	}
+	key, err := q.keyOf(bus, topic, args)
+	if err != nil {
+		task.drop()
+		return err
+	}
+	q.lock.Lock()
+	pending := q.queues[key]
+	q.queues[key] = append(pending, task)
+	q.lock.Unlock()
+	if len(pending) > 0 {
+		return nil // 等待同一个键的前序任务
+	}
+	return bus.runAsync(q.step(bus, key, task))
+}
+
+// keyOf returns the key of the arguments, a panic of the key function is returned as *PanicError
+func (q *orderedQueues) keyOf(bus *EventBus, topic string, args []interface{}) (key string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			bus.recovered(topic, q.key, r, stack)
+			err = &PanicError{Topic: topic, Recovered: r, Stack: stack}
+		}
+	}()
+	return q.key(args), nil
+}
+
+// step runs the task, then submits the next task of the key
+func (q *orderedQueues) step(bus *EventBus, key string, task asyncTask) asyncTask {
+	return asyncTask{
+		run: func() {
+			task.run()
+			q.next(bus, key)
+		},
+		drop: func() {
+			task.drop()
+			q.next(bus, key)
+		},
+	}
+}
+
+// next removes the finished task of the key and hands the following one over without blocking,
+// it is called by a worker of the pool.
+func (q *orderedQueues) next(bus *EventBus, key string) {
+	q.lock.Lock()
+	pending := q.queues[key][1:]
+	if len(pending) == 0 {
+		delete(q.queues, key)
+	} else {
+		q.queues[key] = pending
+	}
+	q.lock.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	step := q.step(bus, key, pending[0])
+	if bus.pool == nil || bus.pool.stopped() || !bus.pool.offer(step) {
+		go step.run() // 队列已满，不阻塞工作者
+	}
+}
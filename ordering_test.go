@@ -0,0 +1,129 @@
+package EventBus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestOrderingKey(t *testing.T) {
+	bus := EventBus.New(EventBus.WithWorkerPool(4, 100, EventBus.OverflowBlock)).(*EventBus.EventBus)
+	var lock sync.Mutex
+	values := map[string][]int{}
+	var running, maxRunning int32
+	handler := func(order string, i int) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		lock.Lock()
+		if n > maxRunning {
+			maxRunning = n
+		}
+		lock.Unlock()
+		time.Sleep(time.Duration(5-i%5) * time.Millisecond) // the first events are the slowest
+		lock.Lock()
+		values[order] = append(values[order], i)
+		lock.Unlock()
+	}
+	key := func(args []interface{}) string { return args[0].(string) }
+	bus.SubscribeWithOptions("orders", handler, EventBus.WithOrderingKey(key))
+
+	for i := 0; i < 10; i++ {
+		for _, order := range []string{"a", "b", "c"} {
+			bus.Publish("orders", order, i)
+		}
+	}
+	if err := bus.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, order := range []string{"a", "b", "c"} {
+		if fmt.Sprint(values[order]) != "[0 1 2 3 4 5 6 7 8 9]" {
+			t.Fatal(order, values[order])
+		}
+	}
+	if maxRunning < 2 {
+		t.Fatal("the keys did not run in parallel", maxRunning)
+	}
+	bus.Close(context.Background())
+}
+
+func TestOrderingKeyContext(t *testing.T) {
+	bus := EventBus.New().(*EventBus.EventBus)
+	rec := &recorder{}
+	key := func(args []interface{}) string { return fmt.Sprint(args[0].(int) % 2) }
+	bus.SubscribeWithOptions("topic", func(ctx context.Context, i int) { rec.add(i) }, EventBus.WithOrderingKey(key))
+
+	// the context passed as first argument is not passed to key
+	bus.Publish("topic", context.Background(), 1)
+	bus.Drain(context.Background())
+	if rec.String() != "[1]" {
+		t.Fatal(rec)
+	}
+}
+
+func TestOrderingKeyFullPool(t *testing.T) {
+	bus := EventBus.New(EventBus.WithWorkerPool(1, 1, EventBus.OverflowBlock)).(*EventBus.EventBus)
+	rec := &recorder{}
+	release := make(chan struct{})
+	bus.SubscribeWithOptions("orders", func(order string, i int) {
+		if i == 1 && order == "a" {
+			<-release
+		}
+		rec.add(i)
+	}, EventBus.WithOrderingKey(func(args []interface{}) string { return args[0].(string) }))
+
+	done := make(chan struct{})
+	go func() {
+		bus.Publish("orders", "a", 1) // runs on the worker
+		bus.Publish("orders", "b", 2) // fills the queue
+		bus.Publish("orders", "a", 3) // waits behind a1
+		close(release)
+		bus.Publish("orders", "c", 4) // waits for a free slot
+		close(done)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("publisher blocked")
+	}
+	// the worker does not wait for its own queue to hand a3 over
+	if err := bus.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := rec.String(); len(s) != len("[1 2 3 4]") || strings.Index(s, "1") > strings.Index(s, "3") {
+		t.Fatal(s)
+	}
+}
+
+func TestOrderingKeyPanic(t *testing.T) {
+	panics := make(chan interface{}, 1)
+	bus := EventBus.New(EventBus.WithPanicHandler(func(topic string, handler interface{}, recovered interface{}, stack []byte) {
+		panics <- recovered
+	}))
+	rec := &recorder{}
+	bus.SubscribeWithOptions("topic", rec.add, EventBus.WithOrderingKey(func(args []interface{}) string {
+		if args[0].(int) < 0 {
+			panic("negative")
+		}
+		return "key"
+	}))
+
+	err := bus.PublishE("topic", -1)
+	var perr *EventBus.PanicError
+	if !errors.As(err, &perr) || <-panics != "negative" {
+		t.Fatal(err)
+	}
+	bus.Publish("topic", 1)
+	bus.(*EventBus.EventBus).Drain(context.Background())
+	if rec.String() != "[1]" {
+		t.Fatal(rec)
+	}
+}
@@ -0,0 +1,208 @@
+package EventBus
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy determines what happens to an async callback when the queue of the worker pool is full
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // the publisher waits for a free slot
+	OverflowDropNewest                       // the new callback is dropped
+	OverflowDropOldest                       // the oldest queued callback is dropped
+	OverflowError                            // the new callback is dropped and ErrQueueFull is reported
+)
+
+// ErrQueueFull is reported with OverflowError when an async callback does not fit in the queue
+var ErrQueueFull = errors.New("async queue is full")
+
+// WithWorkerPool runs async callbacks on a fixed number of workers instead of a goroutine per callback.
+// Callbacks are queued up to queueSize, the policy determines what happens when the queue is full.
+func WithWorkerPool(workers, queueSize int, policy OverflowPolicy) BusOption {
+	return func(bus *EventBus) {
+		bus.pool = newWorkerPool(workers, queueSize, policy)
+	}
+}
+
+// PoolStats - counters of the worker pool
+type PoolStats struct {
+	Workers    int
+	QueueSize  int    // capacity of the queue
+	QueueDepth int    // callbacks waiting in the queue
+	Submitted  uint64 // callbacks accepted by the queue
+	Completed  uint64 // callbacks run by the workers
+	Dropped    uint64 // callbacks dropped because of the overflow policy
+}
+
+// asyncTask is an async callback, drop releases what the publisher acquired for it
+type asyncTask struct {
+	run  func()
+	drop func()
+}
+
+type workerPool struct {
+	submitted uint64
+	completed uint64
+	dropped   uint64
+	workers   int
+	policy    OverflowPolicy
+	queue     chan asyncTask
+	quit      chan struct{}
+	stopOnce  sync.Once
+}
+
+func newWorkerPool(workers, queueSize int, policy OverflowPolicy) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	pool := &workerPool{
+		workers: workers,
+		policy:  policy,
+		queue:   make(chan asyncTask, queueSize),
+		quit:    make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+func (pool *workerPool) work() {
+	for {
+		select {
+		case task := <-pool.queue:
+			task.run()
+			atomic.AddUint64(&pool.completed, 1)
+		case <-pool.quit:
+			pool.drain(func(task asyncTask) {
+				task.run()
+				atomic.AddUint64(&pool.completed, 1)
+			})
+			return
+		}
+	}
+}
+
+// drain runs the tasks left in the queue
+func (pool *workerPool) drain(run func(task asyncTask)) {
+	for {
+		select {
+		case task := <-pool.queue:
+			run(task)
+		default:
+			return
+		}
+	}
+}
+
+// stop stops the workers, tasks submitted afterwards run in their own goroutine
+func (pool *workerPool) stop() {
+	pool.stopOnce.Do(func() { close(pool.quit) })
+}
+
+// send queues the task, waiting for a free slot, or runs it in its own goroutine once the pool is stopped
+func (pool *workerPool) send(task asyncTask) {
+	select {
+	case pool.queue <- task:
+	case <-pool.quit:
+		go task.run()
+	}
+}
+
+func (pool *workerPool) stopped() bool {
+	select {
+	case <-pool.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// submit queues the task according to the overflow policy.
+// The tasks queued while the pool stops are run by the workers before they exit, or by submit.
+func (pool *workerPool) submit(task asyncTask) error {
+	switch pool.policy {
+	case OverflowDropNewest, OverflowError:
+		select {
+		case pool.queue <- task:
+		default:
+			atomic.AddUint64(&pool.dropped, 1)
+			task.drop()
+			if pool.policy == OverflowError {
+				return ErrQueueFull
+			}
+			return nil
+		}
+	case OverflowDropOldest:
+		for queued := false; !queued; {
+			select {
+			case pool.queue <- task:
+				queued = true
+			default:
+				select {
+				case old := <-pool.queue: // 丢弃最早的任务
+					atomic.AddUint64(&pool.dropped, 1)
+					old.drop()
+				default: // 队列为空，等待空闲的worker
+					pool.send(task)
+					queued = true
+				}
+			}
+		}
+	default:
+		pool.send(task)
+	}
+	if pool.stopped() { // 工作者可能已经退出，运行剩余的任务
+		pool.drain(func(task asyncTask) { go task.run() })
+	}
+	atomic.AddUint64(&pool.submitted, 1)
+	return nil
+}
+
+// offer queues the task if there is a free slot, regardless of the overflow policy
+func (pool *workerPool) offer(task asyncTask) bool {
+	select {
+	case pool.queue <- task:
+	default:
+		return false
+	}
+	if pool.stopped() { // 工作者可能已经退出，运行剩余的任务
+		pool.drain(func(task asyncTask) { go task.run() })
+	}
+	atomic.AddUint64(&pool.submitted, 1)
+	return true
+}
+
+func (pool *workerPool) stats() PoolStats {
+	return PoolStats{
+		Workers:    pool.workers,
+		QueueSize:  cap(pool.queue),
+		QueueDepth: len(pool.queue),
+		Submitted:  atomic.LoadUint64(&pool.submitted),
+		Completed:  atomic.LoadUint64(&pool.completed),
+		Dropped:    atomic.LoadUint64(&pool.dropped),
+	}
+}
+
+// runAsync runs the task on the worker pool, or in a new goroutine if the bus has no pool
+func (bus *EventBus) runAsync(task asyncTask) error {
+	if bus.pool == nil || bus.pool.stopped() {
+		go task.run()
+		return nil
+	}
+	return bus.pool.submit(task)
+}
+
+// PoolStats returns the counters of the worker pool, or zero stats if the bus has no pool
+func (bus *EventBus) PoolStats() PoolStats {
+	if bus.pool == nil {
+		return PoolStats{}
+	}
+	return bus.pool.stats()
+}
@@ -0,0 +1,76 @@
+package EventBus_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestWorkerPool(t *testing.T) {
+	bus := EventBus.New(EventBus.WithWorkerPool(4, 16, EventBus.OverflowBlock))
+	var sum int64
+	bus.SubscribeAsync("topic", func(a int) { atomic.AddInt64(&sum, int64(a)) }, false)
+
+	wgs := []*sync.WaitGroup{}
+	for i := 1; i <= 100; i++ {
+		wgs = append(wgs, bus.PublishWaitAsync("topic", i))
+	}
+	for _, wg := range wgs {
+		bus.WaitAsync(wg)
+	}
+	if sum != 5050 {
+		t.Fatal(sum)
+	}
+	stats := bus.(*EventBus.EventBus).PoolStats()
+	if stats.Workers != 4 || stats.QueueSize != 16 || stats.Submitted != 100 || stats.Completed != 100 {
+		t.Fatal(stats)
+	}
+}
+
+func TestWorkerPoolOverflow(t *testing.T) {
+	policies := []EventBus.OverflowPolicy{EventBus.OverflowDropNewest, EventBus.OverflowDropOldest, EventBus.OverflowError}
+	for _, policy := range policies {
+		bus := EventBus.New(EventBus.WithWorkerPool(1, 1, policy))
+		block := make(chan struct{})
+		started := make(chan struct{})
+		runs := []int{}
+		bus.SubscribeAsync("topic", func(a int) {
+			if a == 0 {
+				close(started)
+				<-block
+			}
+			runs = append(runs, a)
+		}, false)
+
+		bus.Publish("topic", 0) // the worker is busy
+		<-started
+		bus.Publish("topic", 1) // queued
+		err := bus.PublishE("topic", 2)
+		if policy == EventBus.OverflowError {
+			if merr, ok := err.(*EventBus.MultiError); !ok || merr.Errs[0] != EventBus.ErrQueueFull {
+				t.Fatal(policy, err)
+			}
+		} else if err != nil {
+			t.Fatal(policy, err)
+		}
+		if stats := bus.(*EventBus.EventBus).PoolStats(); stats.Dropped != 1 || stats.QueueDepth != 1 {
+			t.Fatal(policy, stats)
+		}
+		close(block)
+		for bus.(*EventBus.EventBus).PoolStats().Completed < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		bus.WaitAsync(bus.PublishWaitAsync("topic", 3))
+
+		expected := 1
+		if policy == EventBus.OverflowDropOldest {
+			expected = 2
+		}
+		if len(runs) != 3 || runs[1] != expected || runs[2] != 3 {
+			t.Fatal(policy, runs)
+		}
+	}
+}
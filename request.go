@@ -0,0 +1,205 @@
+package EventBus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+var (
+	// ErrNoResponder is returned by Request when no responder is registered for the topic
+	ErrNoResponder = errors.New("no responder")
+	// ErrArgumentMismatch is returned when the arguments do not match the parameters of the handler
+	ErrArgumentMismatch = errors.New("arguments do not match the handler")
+)
+
+//BusRequester defines request/reply bus behavior
+type BusRequester interface {
+	Respond(topic string, fn interface{}) (Subscription, error)
+	Request(ctx context.Context, topic string, args ...interface{}) ([]interface{}, error)
+	RequestAll(ctx context.Context, topic string, args ...interface{}) ([][]interface{}, error)
+}
+
+// Respond registers fn as the responder of the topic, a topic has at most one responder.
+// The responder is called by Request and RequestAll, not by Publish.
+// Returns error if `fn` is not a function or the topic already has a responder.
+func (bus *EventBus) Respond(topic string, fn interface{}) (Subscription, error) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+	if bus.Closed() {
+		return nil, ErrBusClosed
+	}
+	if !(reflect.TypeOf(fn).Kind() == reflect.Func) {
+		return nil, fmt.Errorf("%s is not of type reflect.Func", reflect.TypeOf(fn).Kind())
+	}
+	if _, ok := bus.responders.Load(topic); ok {
+		return nil, fmt.Errorf("topic %s already has a responder", topic)
+	}
+	if err := bus.checkDeclared(topic, fn); err != nil {
+		return nil, err
+	}
+	bus.sequence++
+	handler := &eventHandler{
+		id: bus.sequence, topic: topic, bus: bus, active: 1, responder: true, callBack: reflect.ValueOf(fn),
+		envelope: takesEnvelope(reflect.TypeOf(fn)),
+	}
+	bus.responders.Store(topic, handler)
+	return handler, nil
+}
+
+// removeResponder is called with bus.lock held
+func (bus *EventBus) removeResponder(handler *eventHandler) bool {
+	if h, ok := bus.responders.Load(handler.topic); !ok || h != handler {
+		return false
+	}
+	bus.responders.Delete(handler.topic)
+	atomic.StoreInt32(&handler.active, 0)
+	return true
+}
+
+// reply is the outcome of a handler called by a request
+type reply struct {
+	results []interface{}
+	err     error
+}
+
+// request calls the handler in a new goroutine and passes its reply to done.
+// A transactional handler is called once its async callbacks in progress are done.
+func (bus *EventBus) request(topic string, handler *eventHandler, arguments []reflect.Value, args []interface{}, done func(reply)) {
+	call := bus.running.add(topic, handler)
+	go func() {
+		defer bus.running.done(call)
+		if handler.transactional {
+			handler.Lock()
+			defer handler.Unlock()
+		}
+		results, err := bus.call(topic, handler, arguments, args)
+		done(reply{results: replyResults(handler, results), err: err})
+	}()
+}
+
+// replyResults converts the results of the handler, without the trailing error
+func replyResults(handler *eventHandler, results []reflect.Value) []interface{} {
+	if len(results) == 0 {
+		return nil // 无返回值或者panic
+	}
+	if n := handler.callBack.Type().NumOut(); n > 0 && handler.callBack.Type().Out(n-1) == errorType {
+		results = results[:len(results)-1]
+	}
+	values := make([]interface{}, len(results))
+	for i, v := range results {
+		values[i] = v.Interface()
+	}
+	return values
+}
+
+// Request calls the responder of the topic and returns its results. If the last result of the
+// responder is an error, it is returned as err and removed from the results.
+// A responder whose first parameter is a context.Context receives ctx, Request returns
+// ctx.Err() if ctx is done before the responder returns.
+func (bus *EventBus) Request(ctx context.Context, topic string, args ...interface{}) ([]interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if bus.Closed() {
+		return nil, ErrBusClosed
+	}
+	h, ok := bus.responders.Load(topic)
+	if !ok {
+		return nil, ErrNoResponder
+	}
+	handler := h.(*eventHandler)
+	var event *Event
+	if handler.envelope {
+		event = bus.newEvent(topic, args)
+	}
+	arguments, ok := bus.arguments(ctx, handler, event, args)
+	if !ok {
+		return nil, bus.mismatch(topic, handler, args)
+	}
+	out := make(chan reply, 1)
+	bus.request(topic, handler, arguments, args, func(r reply) { out <- r })
+	select {
+	case r := <-out:
+		return r.results, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RequestAll calls the responder and every handler subscribed to the topic concurrently,
+// and returns the results of those which succeed, in the order of the handlers.
+// The errors of the handlers are returned as *MultiError, with ctx.Err() if ctx is done before
+// all handlers return. Handlers whose parameters do not match args are skipped, as well as the handlers of
+// SubscribeInBatches and those subscribed with WithDebounce, WithThrottle, WithRateLimit or WithOrderingKey.
+// Transactional handlers are called serially with their async callbacks, retry policies do not apply.
+func (bus *EventBus) RequestAll(ctx context.Context, topic string, args ...interface{}) ([][]interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if bus.Closed() {
+		return nil, ErrBusClosed
+	}
+	handlers := bus.topics().match(topic)
+	if h, ok := bus.responders.Load(topic); ok {
+		handlers = append([]*eventHandler{h.(*eventHandler)}, handlers...)
+	}
+
+	var event *Event
+	if wantsEnvelope(handlers) {
+		event = bus.newEvent(topic, args)
+	}
+
+	type indexed struct {
+		reply
+		idx int
+	}
+	out := make(chan indexed, len(handlers))
+	count := 0
+	for _, handler := range handlers {
+		if handler.batch != nil || handler.limiter != nil || handler.ordering != nil {
+			continue // 批量处理器不返回结果，限流和有序的处理器不能被直接调用
+		}
+		arguments, ok := bus.arguments(ctx, handler, event, args)
+		if !ok || !handler.Active() {
+			continue
+		}
+		if handler.flagOnce {
+			if !atomic.CompareAndSwapInt32(&handler.fired, 0, 1) {
+				continue
+			}
+			bus.removeHandler(handler)
+		}
+		idx := count
+		bus.request(topic, handler, arguments, args, func(r reply) { out <- indexed{r, idx} })
+		count++
+	}
+
+	replies := make([]*reply, count)
+	var errs []error
+	for received := 0; received < count; received++ {
+		select {
+		case r := <-out:
+			if r.err != nil {
+				errs = append(errs, r.err)
+			} else {
+				replies[r.idx] = &r.reply
+			}
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+			received = count // 超时，停止等待
+		}
+	}
+	results := make([][]interface{}, 0, count)
+	for _, r := range replies {
+		if r != nil {
+			results = append(results, r.results)
+		}
+	}
+	if len(errs) > 0 {
+		return results, NewMultiError(&errs)
+	}
+	return results, nil
+}
@@ -0,0 +1,117 @@
+package EventBus_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestRequest(t *testing.T) {
+	bus := EventBus.New()
+	sub, err := bus.Respond("math:div", func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bus.Respond("math:div", func() {}); err == nil {
+		t.Fail()
+	}
+
+	results, err := bus.Request(context.Background(), "math:div", 10, 2)
+	if err != nil || len(results) != 1 || results[0] != 5 {
+		t.Fatal(results, err)
+	}
+	if _, err := bus.Request(context.Background(), "math:div", 10, 0); err == nil || err.Error() != "division by zero" {
+		t.Fatal(err)
+	}
+	if _, err := bus.Request(context.Background(), "math:div", "10"); err != EventBus.ErrArgumentMismatch {
+		t.Fatal(err)
+	}
+	// responders are not called by Publish
+	if bus.HasCallback("math:div") {
+		t.Fail()
+	}
+
+	sub.Unsubscribe()
+	if _, err := bus.Request(context.Background(), "math:div", 10, 2); err != EventBus.ErrNoResponder {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	bus := EventBus.New()
+	bus.Respond("slow", func(ctx context.Context) string {
+		<-ctx.Done()
+		return "late"
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := bus.Request(ctx, "slow"); err != context.DeadlineExceeded {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestAll(t *testing.T) {
+	bus := EventBus.New()
+	bus.Respond("health", func() string { return "responder" })
+	bus.Subscribe("health", func() string { return "db" })
+	bus.Subscribe("#", func() (string, error) { return "", errors.New("cache down") })
+	bus.SubscribeAsync("health", func() string {
+		time.Sleep(time.Second)
+		return "slow"
+	}, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	results, err := bus.RequestAll(ctx, "health")
+	if len(results) != 2 || results[0][0] != "responder" || results[1][0] != "db" {
+		t.Fatal(results)
+	}
+	merr, ok := err.(*EventBus.MultiError)
+	if !ok || len(merr.Errs) != 2 || merr.Errs[0].Error() != "cache down" || merr.Errs[1] != context.DeadlineExceeded {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestPanic(t *testing.T) {
+	bus := EventBus.New(EventBus.WithPanicHandler(func(string, interface{}, interface{}, []byte) {}))
+	bus.Respond("topic", func() (int, error) { panic("boom") })
+	if _, err := bus.Request(context.Background(), "topic"); err == nil {
+		t.Fail()
+	} else if _, ok := err.(*EventBus.PanicError); !ok {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestAllTransactional(t *testing.T) {
+	bus := EventBus.New()
+	var running, overlaps int32
+	bus.SubscribeAsync("topic", func(i int) int {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return i
+	}, true)
+	bus.SubscribeWithOptions("topic", func(i int) int { return -i }, EventBus.WithDebounce(time.Millisecond))
+	bus.SubscribeWithOptions("topic", func(i int) int { return -i }, EventBus.WithOrderingKey(func([]interface{}) string { return "" }))
+
+	bus.Publish("topic", 1)
+	bus.Publish("topic", 2)
+	// the transactional handler is not called concurrently with its async callbacks,
+	// the limited and ordered handlers are skipped
+	results, err := bus.RequestAll(context.Background(), "topic", 3)
+	if err != nil || len(results) != 1 || results[0][0] != 3 || atomic.LoadInt32(&overlaps) != 0 {
+		t.Fatal(results, err, overlaps)
+	}
+	bus.(*EventBus.EventBus).Drain(context.Background())
+}
@@ -0,0 +1,130 @@
+package EventBus
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// storedEvent is an event kept for the handlers subscribed later,
+// as the retained event or in the history of its topic
+type storedEvent struct {
+	seq   uint64
+	topic string // topic the event was published to
+	event *Event
+}
+
+// WithReplayRetained delivers the retained events of the topics matching the subscription
+// to the new handler, before any event published afterwards.
+func WithReplayRetained() SubscribeOption {
+	return func(handler *eventHandler) {
+		handler.replay = true
+	}
+}
+
+// PublishRetained works like Publish and stores the event as the last event of the topic,
+// it is delivered to the handlers subscribed later with WithReplayRetained.
+func (bus *EventBus) PublishRetained(topic string, args ...interface{}) {
+	for _, err := range bus.publish(context.Background(), &sync.WaitGroup{}, true, topic, args...) {
+		bus.handleError(topic, err)
+	}
+}
+
+// ClearRetained removes the retained event of the topic
+func (bus *EventBus) ClearRetained(topic string) {
+	bus.retainLock.Lock()
+	defer bus.retainLock.Unlock()
+	delete(bus.retained, topic)
+}
+
+// store keeps the event as retained event and/or in the history of the topic,
+// and returns the handlers to dispatch it to
+func (bus *EventBus) store(topic string, event *Event, retain bool, history *historyConfig) []*eventHandler {
+	bus.retainLock.Lock()
+	defer bus.retainLock.Unlock()
+	bus.retainSeq++
+	stored := &storedEvent{seq: bus.retainSeq, topic: topic, event: event}
+	if retain {
+		if bus.retained == nil {
+			bus.retained = make(map[string]*storedEvent)
+		}
+		bus.retained[topic] = stored
+	}
+	if history != nil {
+		bus.record(stored, history)
+	}
+	return bus.topics().match(topic)
+}
+
+// subscribeReplay subscribes the handler and delivers the stored events matching its topic.
+// Holding retainLock, every stored event is either replayed or dispatched to the handler by its publisher.
+func (bus *EventBus) subscribeReplay(topic string, fn interface{}, handler *eventHandler) (Subscription, error) {
+	handler.gate = &replayGate{replaying: true}
+	bus.retainLock.Lock()
+	sub, err := bus.addHandler(topic, fn, handler)
+	if err != nil {
+		bus.retainLock.Unlock()
+		return nil, err
+	}
+	events := make([]*storedEvent, 0)
+	if handler.replay {
+		for t, event := range bus.retained {
+			if TopicMatch(topic, t) {
+				events = append(events, event)
+			}
+		}
+	}
+	if handler.history {
+		events = append(events, bus.historySince(topic, handler.since)...)
+	}
+	bus.retainLock.Unlock()
+
+	sort.Slice(events, func(i, j int) bool { return events[i].seq < events[j].seq })
+	wg := &sync.WaitGroup{}
+	for i, event := range events {
+		if i > 0 && events[i-1] == event {
+			continue // 保留的事件也在历史中
+		}
+		if err := bus.dispatch(context.Background(), wg, handler, event.event, event.topic, event.event.Payload); err != nil {
+			bus.handleError(event.topic, err)
+		}
+	}
+	handler.gate.release()
+	return sub, nil
+}
+
+// replayGate holds back the events published to a handler while it replays
+type replayGate struct {
+	lock      sync.Mutex
+	replaying bool
+	pending   []func()
+}
+
+// hold queues the delivery and returns true if the handler is replaying
+func (g *replayGate) hold(deliver func()) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if !g.replaying {
+		return false
+	}
+	g.pending = append(g.pending, deliver)
+	return true
+}
+
+// release runs the deliveries held back, then lets the events through
+func (g *replayGate) release() {
+	for {
+		g.lock.Lock()
+		pending := g.pending
+		g.pending = nil
+		if len(pending) == 0 {
+			g.replaying = false
+			g.lock.Unlock()
+			return
+		}
+		g.lock.Unlock()
+		for _, deliver := range pending {
+			deliver()
+		}
+	}
+}
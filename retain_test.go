@@ -0,0 +1,65 @@
+package EventBus_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestPublishRetained(t *testing.T) {
+	bus := EventBus.New()
+	bus.PublishRetained("config:loaded", "v1")
+	bus.PublishRetained("config:loaded", "v2")
+	bus.PublishRetained("config:saved", "v2")
+	bus.Publish("config:changed", "not retained")
+
+	values := []string{}
+	bus.SubscribeWithOptions("config:*", func(v string) { values = append(values, v) }, EventBus.WithReplayRetained())
+	if fmt.Sprint(values) != "[v2 v2]" {
+		t.Fatal(values)
+	}
+	// subscribers without the option get no replay
+	late := 0
+	bus.Subscribe("config:loaded", func(v string) { late++ })
+	if late != 0 {
+		t.Fail()
+	}
+
+	bus.ClearRetained("config:loaded")
+	values = values[:0]
+	bus.SubscribeWithOptions("config:loaded", func(v string) { values = append(values, v) }, EventBus.WithReplayRetained())
+	if len(values) != 0 {
+		t.Fatal(values)
+	}
+}
+
+func TestPublishRetainedConcurrent(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		bus := EventBus.New()
+		var wg sync.WaitGroup
+		var last int64 = -1
+		var calls, disorder int64
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				bus.PublishRetained("state", i)
+			}
+		}()
+		bus.SubscribeWithOptions("state", func(i int) {
+			atomic.AddInt64(&calls, 1)
+			if int64(i) <= atomic.LoadInt64(&last) {
+				atomic.AddInt64(&disorder, 1)
+			}
+			atomic.StoreInt64(&last, int64(i))
+		}, EventBus.WithReplayRetained())
+		wg.Wait()
+		// no duplicates and no inversion between replay and live events
+		if disorder != 0 || last != 199 {
+			t.Fatal(round, disorder, last, calls)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package EventBus
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// RetryPolicy retries the failed callbacks of an async handler with exponential backoff
+type RetryPolicy struct {
+	MaxAttempts    int           // number of calls including the first one, the callback is not retried if < 2
+	InitialBackoff time.Duration // delay before the first retry
+	MaxBackoff     time.Duration // upper bound of the delay, 0: no bound
+	Multiplier     float64       // growth of the delay after each retry, 2 if < 1
+	Jitter         float64       // random fraction, 0 to 1, removed from each delay
+	// Retryable returns true if the callback should be retried after err, nil retries every error
+	Retryable func(err error) bool
+	// OnFailure is called with the last error once the callback is not retried anymore
+	OnFailure func(topic string, args []interface{}, err error, attempts int)
+}
+
+// WithRetry retries the failed callbacks of an async handler according to the policy.
+// The callback is retried in the goroutine or worker of the async call, a transactional handler
+// receives the next event once the retries are done. Retries stop when ctx of the publisher is done
+// or the bus is closed, the last error is passed to OnFailure and to the error handler of the bus.
+func WithRetry(policy RetryPolicy) SubscribeOption {
+	return func(handler *eventHandler) {
+		handler.retry = &policy
+	}
+}
+
+// backoff returns the delay before the retry following the attempt
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// sleep waits for the delay, returns false if ctx is done or the bus is closed before
+func sleep(ctx context.Context, quit <-chan struct{}, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-quit:
+		return false
+	}
+}
+
+// invokeRetry works like invoke and retries the handler according to its retry policy
+func (bus *EventBus) invokeRetry(ctx context.Context, topic string, handler *eventHandler, arguments []reflect.Value, args []interface{}) error {
+	policy := handler.retry
+	for attempt := 1; ; attempt++ {
+		_, err := bus.call(topic, handler, arguments, args)
+		if err == nil || errors.Is(err, ErrStopPropagation) {
+			return err
+		}
+		if attempt >= policy.MaxAttempts || !policy.retryable(err) || !sleep(ctx, bus.quit, policy.backoff(attempt)) {
+			if policy.OnFailure != nil {
+				policy.OnFailure(topic, args, err, attempt)
+			}
+			bus.failed(topic, handler, args, err)
+			return err
+		}
+	}
+}
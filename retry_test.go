@@ -0,0 +1,134 @@
+package EventBus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestRetry(t *testing.T) {
+	bus := EventBus.New()
+	var calls int32
+	failures := make(chan int, 1)
+	sub, _ := bus.SubscribeWithOptions("topic", func(n int) error {
+		if atomic.AddInt32(&calls, 1) < int32(n) {
+			return errors.New("failure")
+		}
+		return nil
+	}, EventBus.WithAsync(true), EventBus.WithRetry(EventBus.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Jitter:         0.5,
+		OnFailure: func(topic string, args []interface{}, err error, attempts int) {
+			failures <- attempts
+		},
+	}))
+
+	bus.PublishWaitAsync("topic", 3).Wait() // succeeds on the third attempt
+	if calls != 3 || len(failures) != 0 {
+		t.Fatal(calls)
+	}
+	atomic.StoreInt32(&calls, 0)
+	bus.PublishWaitAsync("topic", 5).Wait()
+	if calls != 3 || <-failures != 3 {
+		t.Fatal(calls)
+	}
+	if stats := sub.Stats(); stats.Calls != 6 || stats.Errors != 5 {
+		t.Fatal(stats)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	permanent := errors.New("permanent")
+	var errs []error
+	var lock sync.Mutex
+	bus := EventBus.New(EventBus.WithErrorHandler(func(topic string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		errs = append(errs, err)
+	}))
+	var calls int32
+	bus.SubscribeWithOptions("topic", func() error {
+		atomic.AddInt32(&calls, 1)
+		return permanent
+	}, EventBus.WithAsync(false), EventBus.WithRetry(EventBus.RetryPolicy{
+		MaxAttempts: 5,
+		Retryable:   func(err error) bool { return err != permanent },
+	}))
+	bus.PublishWaitAsync("topic").Wait()
+	if calls != 1 || len(errs) != 1 || errs[0] != permanent {
+		t.Fatal(calls, errs)
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	bus := EventBus.New()
+	var calls int32
+	bus.SubscribeWithOptions("topic", func() error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("failure")
+	}, EventBus.WithAsync(false), EventBus.WithRetry(EventBus.RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: time.Hour,
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.PublishContext(ctx, "topic")
+	time.Sleep(10 * time.Millisecond)
+	cancel() // stops waiting for the next attempt
+	if err := bus.Drain(context.Background()); err != nil || calls != 1 {
+		t.Fatal(err, calls)
+	}
+}
+
+func TestRetryClose(t *testing.T) {
+	bus := EventBus.New()
+	var calls int32
+	bus.SubscribeWithOptions("topic", func() error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("failure")
+	}, EventBus.WithAsync(false), EventBus.WithRetry(EventBus.RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: time.Hour,
+	}))
+	bus.Publish("topic")
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := bus.(*EventBus.EventBus).Close(ctx); err != nil || atomic.LoadInt32(&calls) != 1 {
+		t.Fatal(err, calls) // Close stops waiting for the next attempt
+	}
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	bus := EventBus.New()
+	var lock sync.Mutex
+	var values []int
+	double := func(next EventBus.Invoker) EventBus.Invoker {
+		return func(topic string, handler EventBus.Subscription, args []reflect.Value) ([]reflect.Value, error) {
+			args[0] = reflect.ValueOf(int(args[0].Int()) * 2)
+			return next(topic, handler, args)
+		}
+	}
+	bus.SubscribeWithOptions("topic", func(n int) error {
+		lock.Lock()
+		defer lock.Unlock()
+		values = append(values, n)
+		return errors.New("failure")
+	}, EventBus.WithAsync(false), EventBus.WithMiddleware(double), EventBus.WithRetry(EventBus.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}))
+	bus.PublishWaitAsync("topic", 1).Wait()
+	// every attempt receives the published arguments
+	if fmt.Sprint(values) != "[2 2 2]" {
+		t.Fatal(values)
+	}
+}
@@ -0,0 +1,290 @@
+package EventBus
+
+import (
+	"container/heap"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ScheduledEvent is the handle of an event published later, see PublishAfter, PublishAt and PublishEvery
+type ScheduledEvent interface {
+	// Cancel cancels the event, returns false if it is already published or cancelled
+	Cancel() bool
+	// Topic returns the topic the event is published to
+	Topic() string
+	// Next returns when the event is published next, zero once it is published or cancelled
+	Next() time.Time
+}
+
+// recurrence returns the time of the run following t, zero if there is none
+type recurrence interface {
+	after(t time.Time) time.Time
+}
+
+// interval is the recurrence of PublishEvery
+type interval time.Duration
+
+func (d interval) after(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// scheduledEvent is an entry of the timer heap of the scheduler
+type scheduledEvent struct {
+	scheduler *scheduler
+	id        uint64
+	spec      string // cron expression, see Schedule
+	topic     string
+	next      time.Time
+	repeat    recurrence // nil: published once
+	missed    MissedRunPolicy
+	args      func() []interface{}
+	index     int // index in the heap, -1 once removed
+	cancelled bool
+}
+
+// Cancel removes the event from the scheduler
+func (e *scheduledEvent) Cancel() bool {
+	if e.scheduler == nil {
+		return false
+	}
+	return e.scheduler.remove(e)
+}
+
+// Topic returns the topic the event is published to
+func (e *scheduledEvent) Topic() string {
+	return e.topic
+}
+
+// Next returns when the event is published next
+func (e *scheduledEvent) Next() time.Time {
+	if e.scheduler == nil {
+		return time.Time{}
+	}
+	e.scheduler.lock.Lock()
+	defer e.scheduler.lock.Unlock()
+	if e.index < 0 {
+		return time.Time{}
+	}
+	return e.next
+}
+
+// arguments calls the arguments factory of the event, a panic is passed to the panic handler of the bus
+// and the run is skipped.
+func (e *scheduledEvent) arguments(bus *EventBus) (args []interface{}, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			bus.recovered(e.topic, e.args, r, debug.Stack())
+			args, ok = nil, false
+		}
+	}()
+	return e.args(), true
+}
+
+// timerHeap orders the scheduled events by time
+type timerHeap []*scheduledEvent
+
+func (h timerHeap) Len() int           { return len(h) }
+func (h timerHeap) Less(i, j int) bool { return h[i].next.Before(h[j].next) }
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x interface{}) {
+	e := x.(*scheduledEvent)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *timerHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*h = old[:len(old)-1]
+	return e
+}
+
+// scheduler publishes the scheduled events of a bus from a single goroutine,
+// it is started by the first scheduled event.
+type scheduler struct {
+	lock     sync.Mutex
+	sequence uint64
+	timers   timerHeap
+	wake     chan struct{} // the first event changed
+	quit     chan struct{}
+	stopped  bool
+}
+
+// add schedules the event, returns false if the scheduler is stopped
+func (s *scheduler) add(bus *EventBus, e *scheduledEvent) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopped {
+		return false
+	}
+	if s.quit == nil {
+		s.wake = make(chan struct{}, 1)
+		s.quit = make(chan struct{})
+		go s.run(bus)
+	}
+	s.sequence++
+	e.id = s.sequence
+	e.scheduler = s
+	heap.Push(&s.timers, e)
+	if e.index == 0 {
+		s.notify()
+	}
+	return true
+}
+
+// remove unschedules the event, returns false if it is not scheduled
+func (s *scheduler) remove(e *scheduledEvent) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if e.index < 0 {
+		return false
+	}
+	heap.Remove(&s.timers, e.index)
+	e.cancelled = true
+	return true
+}
+
+// isCancelled returns true if the event was cancelled by Cancel or by stop
+func (s *scheduler) isCancelled(e *scheduledEvent) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return e.cancelled
+}
+
+// notify wakes up the scheduler, it is called with the lock held
+func (s *scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stop cancels the scheduled events and stops the goroutine of the scheduler
+func (s *scheduler) stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	for _, e := range s.timers {
+		e.index = -1
+		e.cancelled = true
+	}
+	s.timers = nil
+	if s.quit != nil {
+		close(s.quit)
+	}
+}
+
+func (s *scheduler) run(bus *EventBus) {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		s.lock.Lock()
+		if len(s.timers) > 0 {
+			timer.Reset(time.Until(s.timers[0].next))
+		}
+		s.lock.Unlock()
+
+		select {
+		case <-timer.C:
+			for _, e := range s.due(time.Now()) {
+				if e.repeat != nil && s.isCancelled(e) {
+					continue // 已取消
+				}
+				if args, ok := e.arguments(bus); ok {
+					bus.Publish(e.topic, args...)
+				}
+			}
+		case <-s.wake:
+		case <-s.quit:
+			timer.Stop()
+			return
+		}
+		if !timer.Stop() { // 清空已触发的计时器
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// due removes the events due at now, recurring events are scheduled again.
+// A recurring event is returned once per run to publish, according to its missed run policy.
+func (s *scheduler) due(now time.Time) []*scheduledEvent {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var events []*scheduledEvent
+	for len(s.timers) > 0 && !s.timers[0].next.After(now) {
+		e := s.timers[0]
+		if e.repeat == nil {
+			events = append(events, e)
+			heap.Pop(&s.timers)
+			continue
+		}
+		runs := 0
+		for !e.next.IsZero() && !e.next.After(now) {
+			runs++
+			e.next = e.repeat.after(e.next)
+		}
+		switch {
+		case e.missed == MissedRunAll:
+		case e.missed == MissedRunSkip && runs > 1:
+			runs = 0 // 错过的运行全部跳过
+		default:
+			runs = 1
+		}
+		for i := 0; i < runs; i++ {
+			events = append(events, e)
+		}
+		if e.next.IsZero() {
+			heap.Pop(&s.timers) // 没有后续的运行
+		} else {
+			heap.Fix(&s.timers, 0)
+		}
+	}
+	return events
+}
+
+// schedule adds the event to the scheduler of the bus
+func (bus *EventBus) schedule(e *scheduledEvent) ScheduledEvent {
+	if bus.Closed() || !bus.scheduler.add(bus, e) {
+		e.index = -1
+		bus.handleError(e.topic, ErrBusClosed)
+	}
+	return e
+}
+
+// PublishAfter publishes the event once d has elapsed, the event is cancelled if the bus is closed before.
+// Scheduled events are published one after the other, sync handlers delay the following events.
+func (bus *EventBus) PublishAfter(d time.Duration, topic string, args ...interface{}) ScheduledEvent {
+	return bus.PublishAt(time.Now().Add(d), topic, args...)
+}
+
+// PublishAt publishes the event at t, see PublishAfter
+func (bus *EventBus) PublishAt(t time.Time, topic string, args ...interface{}) ScheduledEvent {
+	return bus.schedule(&scheduledEvent{topic: topic, next: t, args: func() []interface{} { return args }})
+}
+
+// PublishEvery publishes an event every period until it is cancelled or the bus is closed,
+// the arguments of each event are returned by fnArgs, which may be nil.
+// Periods missed because of slow handlers result in a single event. See PublishAfter.
+func (bus *EventBus) PublishEvery(period time.Duration, topic string, fnArgs func() []interface{}) ScheduledEvent {
+	if fnArgs == nil {
+		fnArgs = func() []interface{} { return nil }
+	}
+	if period <= 0 {
+		period = time.Millisecond
+	}
+	return bus.schedule(&scheduledEvent{topic: topic, next: time.Now().Add(period), repeat: interval(period), args: fnArgs})
+}
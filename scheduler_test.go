@@ -0,0 +1,110 @@
+package EventBus_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestPublishAfter(t *testing.T) {
+	bus := EventBus.New()
+	received := make(chan string, 3)
+	bus.Subscribe("topic", func(s string) { received <- s })
+
+	start := time.Now()
+	bus.PublishAfter(40*time.Millisecond, "topic", "second")
+	bus.PublishAt(start.Add(20*time.Millisecond), "topic", "first")
+	cancelled := bus.PublishAfter(30*time.Millisecond, "topic", "cancelled")
+	if cancelled.Next().IsZero() || cancelled.Topic() != "topic" {
+		t.Fail()
+	}
+	if !cancelled.Cancel() || cancelled.Cancel() || !cancelled.Next().IsZero() {
+		t.Fail()
+	}
+
+	if first := <-received; first != "first" {
+		t.Fatal(first)
+	}
+	if second := <-received; second != "second" || time.Since(start) < 40*time.Millisecond {
+		t.Fatal(second)
+	}
+	select {
+	case s := <-received:
+		t.Fatal(s)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
+
+func TestPublishEvery(t *testing.T) {
+	bus := EventBus.New()
+	var count int32
+	ticks := make(chan int, 10)
+	bus.Subscribe("tick", func(n int) { ticks <- n })
+
+	every := bus.PublishEvery(10*time.Millisecond, "tick", func() []interface{} {
+		return []interface{}{int(atomic.AddInt32(&count, 1))}
+	})
+	for i := 1; i <= 3; i++ {
+		if n := <-ticks; n != i {
+			t.Fatal(n)
+		}
+	}
+	every.Cancel()
+	time.Sleep(30 * time.Millisecond)
+	if len(ticks) > 1 {
+		t.Fatal(len(ticks))
+	}
+}
+
+func TestScheduledEventsCancelledOnClose(t *testing.T) {
+	bus := EventBus.New()
+	var calls int32
+	bus.Subscribe("topic", func() { atomic.AddInt32(&calls, 1) })
+	later := bus.PublishAfter(20*time.Millisecond, "topic")
+	every := bus.PublishEvery(10*time.Millisecond, "topic", nil)
+	bus.Close(context.Background())
+	if !later.Next().IsZero() || !every.Next().IsZero() || later.Cancel() {
+		t.Fail()
+	}
+	time.Sleep(40 * time.Millisecond)
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Fatal(calls)
+	}
+	if !bus.PublishAfter(time.Millisecond, "topic").Next().IsZero() {
+		t.Fail()
+	}
+}
+
+func TestScheduledArgumentsPanic(t *testing.T) {
+	panics := make(chan interface{}, 10)
+	bus := EventBus.New(EventBus.WithPanicHandler(func(topic string, handler interface{}, recovered interface{}, stack []byte) {
+		panics <- recovered
+	}))
+	received := make(chan int, 10)
+	bus.Subscribe("topic", func(i int) { received <- i })
+
+	var runs int32
+	every := bus.PublishEvery(5*time.Millisecond, "topic", func() []interface{} {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("factory")
+		}
+		return []interface{}{2}
+	})
+	defer every.Cancel()
+
+	// the panicking run is skipped, the scheduler keeps running
+	if r := <-panics; r != "factory" {
+		t.Fatal(r)
+	}
+	select {
+	case i := <-received:
+		if i != 2 {
+			t.Fatal(i)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("scheduler stopped")
+	}
+}
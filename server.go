@@ -66,8 +66,8 @@ func (server *Server) Started() bool {
 	return server.service.started
 }
 
-func (server *Server) rpcCallback(subscribeArg *SubscribeArg) func(args ...interface{}) {
-	return func(args ...interface{}) {
+func (server *Server) rpcCallback(subscribeArg *SubscribeArg) func(event *Event, args ...interface{}) {
+	return func(event *Event, args ...interface{}) {
 		client, connErr := rpc.DialHTTPPath("tcp", subscribeArg.ClientAddr, subscribeArg.ClientPath)
 		defer client.Close()
 		if connErr != nil {
@@ -76,6 +76,9 @@ func (server *Server) rpcCallback(subscribeArg *SubscribeArg) func(args ...inter
 		clientArg := new(ClientArg)
 		clientArg.Topic = subscribeArg.Topic
 		clientArg.Args = args
+		if event != nil {
+			clientArg.Event = event.with(event.Topic, nil) // 参数由 Args 传递
+		}
 		var reply bool
 		err := client.Call(subscribeArg.ServiceMethod, clientArg, &reply)
 		if err != nil {
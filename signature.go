@@ -0,0 +1,165 @@
+package EventBus
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SignatureError describes why the arguments of an event do not match the parameters of a handler,
+// it wraps ErrArgumentMismatch.
+type SignatureError struct {
+	Topic    string
+	Handler  reflect.Type   // type of the handler
+	Args     []reflect.Type // types of the arguments, nil for a nil argument
+	Mismatch []ParamMismatch
+}
+
+// ParamMismatch is an argument which does not match its parameter
+type ParamMismatch struct {
+	Index    int          // index of the argument
+	Expected reflect.Type // type of the parameter, nil if there are too many arguments
+	Got      reflect.Type // type of the argument, nil if the argument is nil or missing
+}
+
+func (e *SignatureError) Error() string {
+	details := make([]string, 0, len(e.Mismatch))
+	for _, m := range e.Mismatch {
+		switch {
+		case m.Expected == nil:
+			details = append(details, fmt.Sprintf("argument %d: unexpected %v", m.Index, m.Got))
+		case m.Index >= len(e.Args):
+			details = append(details, fmt.Sprintf("argument %d: expected %v, missing", m.Index, m.Expected))
+		default:
+			details = append(details, fmt.Sprintf("argument %d: expected %v, got %v", m.Index, m.Expected, m.Got))
+		}
+	}
+	args := make([]string, len(e.Args))
+	for i, t := range e.Args {
+		args[i] = "nil"
+		if t != nil {
+			args[i] = t.String()
+		}
+	}
+	msg := fmt.Sprintf("%v of %s does not accept (%s)", e.Handler, e.Topic, strings.Join(args, ", "))
+	if len(details) > 0 {
+		msg += ": " + strings.Join(details, "; ")
+	}
+	return msg
+}
+
+func (e *SignatureError) Unwrap() error {
+	return ErrArgumentMismatch
+}
+
+// WithStrictArguments reports the handlers which do not accept the arguments of an event,
+// with a *SignatureError, instead of skipping them.
+func WithStrictArguments() BusOption {
+	return func(bus *EventBus) {
+		bus.strict = true
+	}
+}
+
+// DeclareTopic declares the types of the arguments published to the topic.
+// The handlers subscribed to the topic, or to a pattern matching it, must accept those arguments,
+// otherwise the subscription fails with a *SignatureError.
+// Returns the *SignatureError of the first handler already subscribed which does not accept them.
+func (bus *EventBus) DeclareTopic(topic string, argTypes ...reflect.Type) error {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+	for _, handler := range bus.topics().match(topic) {
+		if handler.batch != nil {
+			continue // 批量处理器接收 []Event
+		}
+		if err := bus.checkSignature(topic, handler.callBack.Type(), argTypes); err != nil {
+			return err
+		}
+	}
+	if h, ok := bus.responders.Load(topic); ok {
+		if err := bus.checkSignature(topic, h.(*eventHandler).callBack.Type(), argTypes); err != nil {
+			return err
+		}
+	}
+	if bus.schemas == nil {
+		bus.schemas = make(map[string][]reflect.Type)
+	}
+	bus.schemas[topic] = argTypes
+	return nil
+}
+
+// checkDeclared checks fn against the declared topics matching the pattern, it is called with bus.lock held
+func (bus *EventBus) checkDeclared(pattern string, fn interface{}) error {
+	for topic, argTypes := range bus.schemas {
+		if TopicMatch(pattern, topic) {
+			if err := bus.checkSignature(topic, reflect.TypeOf(fn), argTypes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mismatch returns the error of a handler which does not accept args
+func (bus *EventBus) mismatch(topic string, handler *eventHandler, args []interface{}) error {
+	if !bus.strict {
+		return ErrArgumentMismatch
+	}
+	types := make([]reflect.Type, len(args))
+	for i, v := range args {
+		if v != nil {
+			types[i] = reflect.TypeOf(v)
+		}
+	}
+	if err := bus.checkSignature(topic, handler.callBack.Type(), types); err != nil {
+		return err
+	}
+	return &SignatureError{Topic: topic, Handler: handler.callBack.Type(), Args: types} // 类型匹配，参数值不被接受，例如数值溢出
+}
+
+// checkSignature returns a *SignatureError if funcType does not accept arguments of the types,
+// following the rules of PassedArgumentsContext.
+func (bus *EventBus) checkSignature(topic string, funcType reflect.Type, types []reflect.Type) *SignatureError {
+	params := make([]reflect.Type, 0, funcType.NumIn())
+	for i := 0; i < funcType.NumIn(); i++ {
+		params = append(params, funcType.In(i))
+	}
+	if takesEnvelope(funcType) {
+		i := 0
+		if params[0] == contextType {
+			i = 1
+		}
+		params = append(params[:i:i], params[i+1:]...) // 信封不是参数
+	}
+	if len(params) > 0 && params[0] == contextType && !(len(types) > 0 && types[0] != nil && types[0].Implements(contextType)) {
+		params = params[1:] // 注入上下文
+	}
+	var variadic reflect.Type
+	if funcType.IsVariadic() {
+		variadic = params[len(params)-1].Elem()
+		params = params[:len(params)-1]
+	}
+	var mismatch []ParamMismatch
+	for i := 0; i < len(params) || i < len(types); i++ {
+		var expected, got reflect.Type
+		switch {
+		case i < len(params):
+			expected = params[i]
+		case variadic != nil:
+			expected = variadic
+		}
+		if i < len(types) {
+			got = types[i]
+		}
+		switch {
+		case i >= len(types), expected == nil:
+			mismatch = append(mismatch, ParamMismatch{Index: i, Expected: expected, Got: got})
+		case got == nil && !nilable(expected),
+			got != nil && !got.AssignableTo(expected) && !(bus.convert && convertible(got, expected)):
+			mismatch = append(mismatch, ParamMismatch{Index: i, Expected: expected, Got: got})
+		}
+	}
+	if len(mismatch) == 0 {
+		return nil
+	}
+	return &SignatureError{Topic: topic, Handler: funcType, Args: types, Mismatch: mismatch}
+}
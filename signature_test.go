@@ -0,0 +1,84 @@
+package EventBus_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestStrictArguments(t *testing.T) {
+	bus := EventBus.New(EventBus.WithStrictArguments())
+	bus.Subscribe("topic", func(s string, i int) {})
+
+	err := bus.PublishE("topic", 1, 2)
+	var sigErr *EventBus.SignatureError
+	if !errors.As(err, &sigErr) || !errors.Is(err, EventBus.ErrArgumentMismatch) {
+		t.Fatal(err)
+	}
+	if len(sigErr.Mismatch) != 1 || sigErr.Mismatch[0].Index != 0 || sigErr.Mismatch[0].Expected != reflect.TypeOf("") {
+		t.Fatal(sigErr.Mismatch)
+	}
+	if msg := sigErr.Error(); !strings.Contains(msg, "argument 0: expected string, got int") {
+		t.Fatal(msg)
+	}
+
+	err = bus.PublishE("topic", "a")
+	if !errors.As(err, &sigErr) || !strings.Contains(sigErr.Error(), "argument 1: expected int, missing") {
+		t.Fatal(err)
+	}
+	err = bus.PublishE("topic", "a", 1, true)
+	if !errors.As(err, &sigErr) || !strings.Contains(sigErr.Error(), "argument 2: unexpected bool") {
+		t.Fatal(err)
+	}
+	if err := bus.PublishE("topic", "a", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// without strict mode the handler is skipped
+	if err := EventBus.New().PublishE("topic", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStrictArgumentsRequest(t *testing.T) {
+	bus := EventBus.New(EventBus.WithStrictArguments())
+	bus.Respond("double", func(ctx context.Context, i int) int { return i * 2 })
+	_, err := bus.Request(context.Background(), "double", "1")
+	var sigErr *EventBus.SignatureError
+	if !errors.As(err, &sigErr) || sigErr.Mismatch[0].Got != reflect.TypeOf("") {
+		t.Fatal(err)
+	}
+}
+
+func TestDeclareTopic(t *testing.T) {
+	bus := EventBus.New().(*EventBus.EventBus)
+	if err := bus.DeclareTopic("orders:created", reflect.TypeOf(""), reflect.TypeOf(0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe("orders:created", func(id string, count int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe("orders:created", func(ctx context.Context, id string, counts ...int) {}); err != nil {
+		t.Fatal(err)
+	}
+	// a pattern is checked against the declared topics it matches
+	err := bus.Subscribe("orders:*", func(id int) {})
+	var sigErr *EventBus.SignatureError
+	if !errors.As(err, &sigErr) || sigErr.Topic != "orders:created" || len(sigErr.Mismatch) != 2 {
+		t.Fatal(err)
+	}
+	if bus.HasCallback("orders:paid") {
+		t.Fail()
+	}
+	if err := bus.Subscribe("users:*", func(id int) {}); err != nil {
+		t.Fatal(err)
+	}
+	// declaring a topic checks the handlers already subscribed
+	if err := bus.DeclareTopic("users:created", reflect.TypeOf("")); err == nil {
+		t.Fail()
+	}
+}
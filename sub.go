@@ -1,6 +1,8 @@
 package EventBus
 
 import (
+	"errors"
+	"fmt"
 	"sort"
 	"strings"
 )
@@ -52,3 +54,39 @@ func (e *MultiError) Error() string {
 	}
 	return sbr.String()
 }
+
+// Unwrap returns the errors, so that errors.Is and errors.As look into them from Go 1.20
+func (e *MultiError) Unwrap() []error {
+	return e.Errs
+}
+
+// Is reports whether one of the errors matches target, for errors.Is before Go 1.20
+func (e *MultiError) Is(target error) bool {
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target, for errors.As before Go 1.20
+func (e *MultiError) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// PanicError is the error reported for a handler which panicked
+type PanicError struct {
+	Topic     string
+	Recovered interface{}
+	Stack     []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in handler of %s: %v", e.Topic, e.Recovered)
+}
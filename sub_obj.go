@@ -12,25 +12,22 @@ import (
  */
 func SubscribeEvent(bus Bus, hdl EventHandler) (func(), error) {
 	kind, topic, handler := hdl.Subscribe()
+	var sub Subscription
+	var err error
 	switch kind {
 	case BusAsync:
-		if err := bus.SubscribeAsync(topic, handler, false); err != nil {
-			return nil, err
-		}
+		sub, err = bus.SubscribeAsyncHandle(topic, handler, false)
 	case BusOnceSync:
-		if err := bus.SubscribeOnce(topic, handler); err != nil {
-			return nil, err
-		}
+		sub, err = bus.SubscribeOnceHandle(topic, handler)
 	case BusOnceAsync:
-		if err := bus.SubscribeOnceAsync(topic, handler); err != nil {
-			return nil, err
-		}
+		sub, err = bus.SubscribeOnceAsyncHandle(topic, handler)
 	default:
-		if err := bus.Subscribe(topic, handler); err != nil {
-			return nil, err
-		}
+		sub, err = bus.SubscribeHandle(topic, handler)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return func() { bus.Unsubscribe(topic, handler) }, nil
+	return func() { sub.Unsubscribe() }, nil
 }
 
 /**
@@ -58,20 +58,22 @@ func SubscribeTag(bus Bus, data interface{}, verify bool, tag string) (func(), e
 			}
 			fn := tfv.MethodByName(name).Interface()
 
+			var sub Subscription
 			var err error
 			// 注意， 内部总线是支持空主题的，空主题="default"主题
 			switch kind {
 			case BusAsync:
-				err = bus.SubscribeAsync(topic, fn, false)
+				sub, err = bus.SubscribeAsyncHandle(topic, fn, false)
 			default:
-				err = bus.Subscribe(topic, fn)
+				sub, err = bus.SubscribeHandle(topic, fn)
 			}
 			if err != nil && verify {
 				return nil, err
 			} else if err != nil {
 				errs = append(errs, err)
 			} else {
-				clss = append(clss, func() { bus.Unsubscribe(topic, fn) })
+				// 方法值的函数指针相同，必须通过句柄取消订阅
+				clss = append(clss, func() { sub.Unsubscribe() })
 			}
 		}
 	}
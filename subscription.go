@@ -0,0 +1,92 @@
+package EventBus
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrStopPropagation can be returned by a sync handler to skip the remaining handlers of the event,
+// it is not reported as an error.
+var ErrStopPropagation = errors.New("stop propagation")
+
+// SubscribeOption configures a handler subscribed with SubscribeWithOptions
+type SubscribeOption func(*eventHandler)
+
+// WithPriority sets the priority of the handler, handlers with a higher priority run first.
+// Handlers with the same priority run in subscription order, the default priority is 0.
+func WithPriority(priority int) SubscribeOption {
+	return func(handler *eventHandler) {
+		handler.priority = priority
+	}
+}
+
+// WithAsync runs the handler asynchronously,
+// transactional determines whether its callbacks are run serially (true) or concurrently (false)
+func WithAsync(transactional bool) SubscribeOption {
+	return func(handler *eventHandler) {
+		handler.async = true
+		handler.transactional = transactional
+	}
+}
+
+// WithOnce removes the handler after executing
+func WithOnce() SubscribeOption {
+	return func(handler *eventHandler) {
+		handler.flagOnce = true
+	}
+}
+
+// Subscription is the handle of a subscribed handler,
+// unlike Unsubscribe(topic, fn) it always removes the handler it was returned for.
+type Subscription interface {
+	// Unsubscribe removes the handler, returns error if it is already removed
+	Unsubscribe() error
+	// Topic returns the topic or pattern subscribed to
+	Topic() string
+	// Active returns false once the handler is removed
+	Active() bool
+	// Stats returns the invocation counters of the handler
+	Stats() SubscriptionStats
+}
+
+// SubscriptionStats - invocation counters of a subscription
+type SubscriptionStats struct {
+	Calls  uint64 // number of invocations
+	Errors uint64 // number of invocations returning an error or panicking
+	Panics uint64 // number of invocations panicking
+}
+
+// handlerStats is updated atomically, keep it first in eventHandler for 64-bit alignment
+type handlerStats struct {
+	calls  uint64
+	errors uint64
+	panics uint64
+}
+
+// Unsubscribe removes the handler from its bus
+func (handler *eventHandler) Unsubscribe() error {
+	if handler.bus == nil || !handler.bus.removeHandler(handler) {
+		return fmt.Errorf("subscription of %s is not active", handler.topic)
+	}
+	return nil
+}
+
+// Topic returns the topic or pattern subscribed to
+func (handler *eventHandler) Topic() string {
+	return handler.topic
+}
+
+// Active returns false once the handler is removed
+func (handler *eventHandler) Active() bool {
+	return atomic.LoadInt32(&handler.active) == 1
+}
+
+// Stats returns the invocation counters of the handler
+func (handler *eventHandler) Stats() SubscriptionStats {
+	return SubscriptionStats{
+		Calls:  atomic.LoadUint64(&handler.stats.calls),
+		Errors: atomic.LoadUint64(&handler.stats.errors),
+		Panics: atomic.LoadUint64(&handler.stats.panics),
+	}
+}
@@ -0,0 +1,135 @@
+package EventBus_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestSubscriptionUnsubscribe(t *testing.T) {
+	bus := EventBus.New()
+	calls := []int{}
+	subs := []EventBus.Subscription{}
+	for i := 0; i < 3; i++ {
+		i := i
+		sub, err := bus.SubscribeHandle("topic", func() { calls = append(calls, i) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		subs = append(subs, sub)
+	}
+
+	if subs[1].Unsubscribe() != nil || subs[1].Active() {
+		t.Fail()
+	}
+	if subs[1].Unsubscribe() == nil {
+		t.Fail()
+	}
+	bus.Publish("topic")
+	if len(calls) != 2 || calls[0] != 0 || calls[1] != 2 {
+		t.Fatal(calls)
+	}
+	if subs[0].Topic() != "topic" || !subs[0].Active() {
+		t.Fail()
+	}
+}
+
+func TestSubscriptionSameFunc(t *testing.T) {
+	bus := EventBus.New()
+	flag := 0
+	fn := func() { flag++ }
+	bus.SubscribeHandle("topic", fn)
+	sub, _ := bus.SubscribeOnceHandle("topic", fn)
+	sub.Unsubscribe()
+	bus.Publish("topic")
+	bus.Publish("topic")
+	if flag != 2 {
+		t.Fatal(flag)
+	}
+}
+
+func TestSubscriptionStats(t *testing.T) {
+	bus := EventBus.New(EventBus.WithPanicHandler(func(string, interface{}, interface{}, []byte) {}))
+	sub, _ := bus.SubscribeHandle("topic", func(a int) error {
+		if a == 1 {
+			return errors.New("failed")
+		}
+		if a == 2 {
+			panic(a)
+		}
+		return nil
+	})
+	once, _ := bus.SubscribeOnceHandle("topic", func(a int) {})
+	for i := 0; i < 3; i++ {
+		bus.Publish("topic", i)
+	}
+	stats := sub.Stats()
+	if stats.Calls != 3 || stats.Errors != 2 || stats.Panics != 1 {
+		t.Fatal(stats)
+	}
+	if once.Active() || once.Stats().Calls != 1 {
+		t.Fail()
+	}
+}
+
+type tagHandler struct {
+	A *counter `gbus:"Inc=~topic"`
+	B *counter `gbus:"Inc=~topic"`
+}
+
+type counter struct {
+	n int
+}
+
+func (c *counter) Inc() { c.n++ }
+
+func TestSubscribeTagUnsubscribe(t *testing.T) {
+	bus := EventBus.New()
+	h := &tagHandler{A: &counter{}, B: &counter{}}
+	clear, err := EventBus.SubscribeTag(bus, h, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Publish("topic")
+	clear()
+	bus.Publish("topic")
+	if h.A.n != 1 || h.B.n != 1 || bus.HasCallback("topic") {
+		t.Fatal(h.A.n, h.B.n)
+	}
+}
+
+func TestSubscribePriority(t *testing.T) {
+	bus := EventBus.New()
+	calls := []string{}
+	bus.SubscribeWithOptions("order:created", func() { calls = append(calls, "effect") })
+	bus.SubscribeWithOptions("order:*", func() { calls = append(calls, "audit") }, EventBus.WithPriority(5))
+	bus.SubscribeWithOptions("order:created", func() { calls = append(calls, "validate") }, EventBus.WithPriority(10))
+	bus.SubscribeWithOptions("order:created", func() { calls = append(calls, "effect2") })
+
+	bus.Publish("order:created")
+	if len(calls) != 4 || calls[0] != "validate" || calls[1] != "audit" || calls[2] != "effect" || calls[3] != "effect2" {
+		t.Fatal(calls)
+	}
+}
+
+func TestStopPropagation(t *testing.T) {
+	bus := EventBus.New()
+	calls := []string{}
+	bus.SubscribeWithOptions("topic", func(valid bool) error {
+		calls = append(calls, "validate")
+		if !valid {
+			return EventBus.ErrStopPropagation
+		}
+		return nil
+	}, EventBus.WithPriority(1))
+	bus.Subscribe("topic", func(valid bool) { calls = append(calls, "effect") })
+
+	if err := bus.PublishE("topic", false); err != nil {
+		t.Fatal(err)
+	}
+	bus.Publish("topic", true)
+	if len(calls) != 3 || calls[0] != "validate" || calls[1] != "validate" || calls[2] != "effect" {
+		t.Fatal(calls)
+	}
+}
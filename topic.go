@@ -0,0 +1,208 @@
+package EventBus
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// TopicSeparator separates the levels of a hierarchical topic, e.g. "order:created:eu"
+	TopicSeparator = ":"
+	// TopicWildcardOne matches exactly one level, e.g. "order:*" matches "order:created"
+	TopicWildcardOne = "*"
+	// TopicWildcardAll matches zero or more levels, e.g. "order:#" matches "order" and "order:created:eu"
+	TopicWildcardAll = "#"
+)
+
+// IsTopicPattern returns true if the topic contains any wildcard level.
+func IsTopicPattern(topic string) bool {
+	for _, level := range strings.Split(topic, TopicSeparator) {
+		if level == TopicWildcardOne || level == TopicWildcardAll {
+			return true
+		}
+	}
+	return false
+}
+
+// TopicMatch returns true if the topic is matched by the pattern.
+func TopicMatch(pattern, topic string) bool {
+	return matchLevels(strings.Split(pattern, TopicSeparator), strings.Split(topic, TopicSeparator))
+}
+
+func matchLevels(pattern, levels []string) bool {
+	if len(pattern) == 0 {
+		return len(levels) == 0
+	}
+	switch pattern[0] {
+	case TopicWildcardAll:
+		for i := 0; i <= len(levels); i++ {
+			if matchLevels(pattern[1:], levels[i:]) {
+				return true
+			}
+		}
+		return false
+	case TopicWildcardOne:
+		return len(levels) > 0 && matchLevels(pattern[1:], levels[1:])
+	default:
+		return len(levels) > 0 && pattern[0] == levels[0] && matchLevels(pattern[1:], levels[1:])
+	}
+}
+
+// topicNode is one level of the subscription index
+type topicNode struct {
+	children map[string]*topicNode
+	handlers []*eventHandler
+}
+
+// topicTrie indexes handlers by topic pattern, so that publishing only walks
+// the levels of the published topic instead of testing every pattern.
+// A trie is never modified, add and remove return a new trie which shares
+// the untouched nodes, so it can be read without lock.
+type topicTrie struct {
+	root *topicNode
+}
+
+func newTopicTrie() *topicTrie {
+	return &topicTrie{root: &topicNode{}}
+}
+
+// clone returns a shallow copy of the node
+func (n *topicNode) clone() *topicNode {
+	c := &topicNode{handlers: n.handlers}
+	if len(n.children) > 0 {
+		c.children = make(map[string]*topicNode, len(n.children))
+		for level, child := range n.children {
+			c.children[level] = child
+		}
+	}
+	return c
+}
+
+// add returns a new trie with the handler added to the node of the pattern
+func (t *topicTrie) add(pattern string, handler *eventHandler) *topicTrie {
+	return &topicTrie{root: t.root.add(strings.Split(pattern, TopicSeparator), handler)}
+}
+
+func (n *topicNode) add(levels []string, handler *eventHandler) *topicNode {
+	c := n.clone()
+	if len(levels) == 0 {
+		// 按优先级插入
+		idx := sort.Search(len(n.handlers), func(i int) bool { return handlerLess(handler, n.handlers[i]) })
+		c.handlers = make([]*eventHandler, 0, len(n.handlers)+1)
+		c.handlers = append(c.handlers, n.handlers[:idx]...)
+		c.handlers = append(c.handlers, handler)
+		c.handlers = append(c.handlers, n.handlers[idx:]...)
+		return c
+	}
+	child, ok := n.children[levels[0]]
+	if !ok {
+		child = &topicNode{}
+	}
+	if c.children == nil {
+		c.children = make(map[string]*topicNode)
+	}
+	c.children[levels[0]] = child.add(levels[1:], handler)
+	return c
+}
+
+// handlerLess orders handlers by priority, then by subscription
+func handlerLess(a, b *eventHandler) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.id < b.id
+}
+
+// remove returns a new trie without the handler, or nil if the pattern has no such handler.
+// Empty nodes are pruned.
+func (t *topicTrie) remove(pattern string, handler *eventHandler) *topicTrie {
+	root := t.root.remove(strings.Split(pattern, TopicSeparator), handler)
+	if root == nil {
+		return nil
+	}
+	return &topicTrie{root: root}
+}
+
+func (n *topicNode) remove(levels []string, handler *eventHandler) *topicNode {
+	if len(levels) == 0 {
+		for idx, h := range n.handlers {
+			if h == handler {
+				c := n.clone()
+				c.handlers = make([]*eventHandler, 0, len(n.handlers)-1)
+				c.handlers = append(c.handlers, n.handlers[:idx]...)
+				c.handlers = append(c.handlers, n.handlers[idx+1:]...)
+				return c
+			}
+		}
+		return nil
+	}
+	child, ok := n.children[levels[0]]
+	if !ok {
+		return nil
+	}
+	if child = child.remove(levels[1:], handler); child == nil {
+		return nil
+	}
+	c := n.clone()
+	if len(child.handlers) == 0 && len(child.children) == 0 {
+		delete(c.children, levels[0]) // 清理空节点
+	} else {
+		c.children[levels[0]] = child
+	}
+	return c
+}
+
+// lookup returns the handlers subscribed exactly to the pattern, the slice must not be modified
+func (t *topicTrie) lookup(pattern string) []*eventHandler {
+	node := t.root
+	for _, level := range strings.Split(pattern, TopicSeparator) {
+		if node = node.children[level]; node == nil {
+			return nil
+		}
+	}
+	return node.handlers
+}
+
+// match returns a new slice with every handler whose pattern matches the topic,
+// ordered by priority and subscription.
+func (t *topicTrie) match(topic string) []*eventHandler {
+	var result []*eventHandler
+	nodes := 0
+	t.root.match(strings.Split(topic, TopicSeparator), &result, &nodes)
+	if nodes > 1 {
+		// 多个模式命中，按优先级和订阅顺序排序并去重
+		sort.Slice(result, func(i, j int) bool { return handlerLess(result[i], result[j]) })
+		uniq := result[:0]
+		for _, h := range result {
+			if len(uniq) == 0 || h != uniq[len(uniq)-1] {
+				uniq = append(uniq, h)
+			}
+		}
+		result = uniq
+	}
+	return result
+}
+
+func (n *topicNode) match(levels []string, result *[]*eventHandler, nodes *int) {
+	if len(levels) == 0 {
+		if len(n.handlers) > 0 {
+			*result = append(*result, n.handlers...)
+			*nodes++
+		}
+		if child, ok := n.children[TopicWildcardAll]; ok {
+			child.match(levels, result, nodes) // '#' matches zero levels
+		}
+		return
+	}
+	if child, ok := n.children[levels[0]]; ok {
+		child.match(levels[1:], result, nodes)
+	}
+	if child, ok := n.children[TopicWildcardOne]; ok {
+		child.match(levels[1:], result, nodes)
+	}
+	if child, ok := n.children[TopicWildcardAll]; ok {
+		for i := 0; i <= len(levels); i++ {
+			child.match(levels[i:], result, nodes)
+		}
+	}
+}
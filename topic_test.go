@@ -0,0 +1,71 @@
+package EventBus_test
+
+import (
+	"testing"
+
+	"github.com/suisrc/EventBus"
+)
+
+func TestTopicMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		topic   string
+		match   bool
+	}{
+		{"order:created:eu", "order:created:eu", true},
+		{"order:*", "order:created", true},
+		{"order:*", "order:created:eu", false},
+		{"order:*:eu", "order:created:eu", true},
+		{"order:#", "order", true},
+		{"order:#", "order:created:eu", true},
+		{"#:eu", "order:created:eu", true},
+		{"#", "order", true},
+		{"order:#", "payment:created", false},
+	}
+	for _, c := range cases {
+		if EventBus.TopicMatch(c.pattern, c.topic) != c.match {
+			t.Errorf("TopicMatch(%q, %q) != %v", c.pattern, c.topic, c.match)
+		}
+	}
+}
+
+func TestSubscribeWildcard(t *testing.T) {
+	bus := EventBus.New()
+	calls := []string{}
+	bus.Subscribe("order:*", func() { calls = append(calls, "one") })
+	bus.Subscribe("order:#", func() { calls = append(calls, "all") })
+	bus.Subscribe("order:created:eu", func() { calls = append(calls, "exact") })
+	bus.Subscribe("#", func() { calls = append(calls, "any") })
+
+	bus.Publish("order:created:eu")
+	if len(calls) != 3 || calls[0] != "all" || calls[1] != "exact" || calls[2] != "any" {
+		t.Fatal(calls)
+	}
+
+	calls = calls[:0]
+	bus.Publish("order:created")
+	if len(calls) != 3 || calls[0] != "one" || calls[1] != "all" || calls[2] != "any" {
+		t.Fatal(calls)
+	}
+}
+
+func TestHasCallbackWildcard(t *testing.T) {
+	bus := EventBus.New()
+	handler := func() {}
+	bus.Subscribe("order:*:eu", handler)
+	if !bus.HasCallback("order:created:eu") {
+		t.Fail()
+	}
+	if !bus.HasCallback("order:*:eu") {
+		t.Fail()
+	}
+	if bus.HasCallback("order:created:us") {
+		t.Fail()
+	}
+	if bus.Unsubscribe("order:*:eu", handler) != nil {
+		t.Fail()
+	}
+	if bus.HasCallback("order:created:eu") {
+		t.Fail()
+	}
+}
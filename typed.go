@@ -0,0 +1,97 @@
+package EventBus
+
+import "reflect"
+
+// typedHandler calls a handler with a known signature without reflection
+type typedHandler interface {
+	accept(args []interface{}) bool
+	call(args []interface{})
+	values(args []interface{}) []reflect.Value
+}
+
+// typedFunc is a handler receiving a single value of type T
+type typedFunc[T any] struct {
+	fn      func(T)
+	nilable bool // nil is accepted as zero value of T
+}
+
+func newTypedFunc[T any](fn func(T)) typedFunc[T] {
+	return typedFunc[T]{fn: fn, nilable: nilable(reflect.TypeOf(fn).In(0))}
+}
+
+func (h typedFunc[T]) accept(args []interface{}) bool {
+	if len(args) != 1 {
+		return false
+	}
+	if args[0] == nil {
+		return h.nilable
+	}
+	_, ok := args[0].(T)
+	return ok
+}
+
+func (h typedFunc[T]) call(args []interface{}) {
+	v, _ := args[0].(T) // nil 转换为零值
+	h.fn(v)
+}
+
+func (h typedFunc[T]) values(args []interface{}) []reflect.Value {
+	v, _ := args[0].(T)
+	return []reflect.Value{reflect.ValueOf(&v).Elem()}
+}
+
+// TypedTopic is a topic whose events carry a single value of type T,
+// the signature of its handlers is checked at compile time.
+type TypedTopic[T any] struct {
+	bus   Bus
+	topic string
+}
+
+// NewTypedTopic returns the typed topic on the bus
+func NewTypedTopic[T any](bus Bus, topic string) *TypedTopic[T] {
+	return &TypedTopic[T]{bus: bus, topic: topic}
+}
+
+// Topic returns the name of the topic
+func (t *TypedTopic[T]) Topic() string {
+	return t.topic
+}
+
+// Subscribe subscribes fn to the topic, see Subscribe
+func (t *TypedTopic[T]) Subscribe(fn func(T)) (Subscription, error) {
+	return Subscribe(t.bus, t.topic, fn)
+}
+
+// SubscribeAsync subscribes fn to the topic with an asynchronous callback, see SubscribeAsync
+func (t *TypedTopic[T]) SubscribeAsync(fn func(T), transactional bool) (Subscription, error) {
+	return SubscribeAsync(t.bus, t.topic, fn, transactional)
+}
+
+// Publish publishes v to the topic
+func (t *TypedTopic[T]) Publish(v T) {
+	Publish(t.bus, t.topic, v)
+}
+
+// Subscribe subscribes fn to a topic of the bus.
+// On an EventBus fn is called directly instead of through reflection,
+// it receives the events of Publish[T] as well as the events of bus.Publish with a single T argument.
+func Subscribe[T any](bus Bus, topic string, fn func(T), opts ...SubscribeOption) (Subscription, error) {
+	if eb, ok := bus.(*EventBus); ok {
+		handler := &eventHandler{callBack: reflect.ValueOf(fn), typed: newTypedFunc(fn)}
+		for _, opt := range opts {
+			opt(handler)
+		}
+		return eb.doSubscribe(topic, fn, handler)
+	}
+	return bus.SubscribeWithOptions(topic, fn, opts...)
+}
+
+// SubscribeAsync subscribes fn to a topic of the bus with an asynchronous callback, see Subscribe[T].
+func SubscribeAsync[T any](bus Bus, topic string, fn func(T), transactional bool) (Subscription, error) {
+	return Subscribe(bus, topic, fn, WithAsync(transactional))
+}
+
+// Publish publishes v to a topic of the bus
+func Publish[T any](bus Bus, topic string, v T) {
+	bus.Publish(topic, v)
+}
@@ -0,0 +1,83 @@
+package EventBus_test
+
+import (
+	"testing"
+
+	"github.com/suisrc/EventBus"
+)
+
+type order struct {
+	ID string
+}
+
+func TestTypedTopic(t *testing.T) {
+	bus := EventBus.New()
+	topic := EventBus.NewTypedTopic[*order](bus, "order:created")
+	ids := []string{}
+	topic.Subscribe(func(o *order) {
+		if o == nil {
+			ids = append(ids, "nil")
+			return
+		}
+		ids = append(ids, o.ID)
+	})
+
+	topic.Publish(&order{ID: "1"})
+	bus.Publish("order:created", &order{ID: "2"})
+	bus.Publish("order:created", nil)
+	bus.Publish("order:created", "3") // skipped, not an order
+	bus.Publish("order:created", &order{ID: "4"}, 5)
+	if len(ids) != 3 || ids[0] != "1" || ids[1] != "2" || ids[2] != "nil" {
+		t.Fatal(ids)
+	}
+}
+
+func TestTypedInterop(t *testing.T) {
+	bus := EventBus.New()
+	sum := 0
+	handler := func(a int) { sum += a }
+	EventBus.Subscribe(bus, "topic", handler)
+	bus.Subscribe("topic", func(a int) { sum += a * 10 })
+	EventBus.Publish(bus, "topic", 1)
+	if sum != 11 {
+		t.Fatal(sum)
+	}
+
+	if bus.Unsubscribe("topic", handler) != nil {
+		t.Fail()
+	}
+	EventBus.Publish(bus, "topic", 1)
+	if sum != 21 {
+		t.Fatal(sum)
+	}
+}
+
+func TestTypedAsync(t *testing.T) {
+	bus := EventBus.New()
+	results := make(chan string, 1)
+	EventBus.SubscribeAsync(bus, "topic", func(s string) { results <- s }, false)
+	bus.WaitAsync(bus.PublishWaitAsync("topic", "async"))
+	if <-results != "async" {
+		t.Fail()
+	}
+}
+
+func BenchmarkPublishReflect(b *testing.B) {
+	bus := EventBus.New()
+	bus.Subscribe("topic", func(o *order) {})
+	o := &order{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bus.Publish("topic", o)
+	}
+}
+
+func BenchmarkPublishTyped(b *testing.B) {
+	bus := EventBus.New()
+	EventBus.Subscribe(bus, "topic", func(o *order) {})
+	o := &order{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		EventBus.Publish(bus, "topic", o)
+	}
+}